@@ -0,0 +1,82 @@
+package cspf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EdgeSpec describes an edge to be added by AddEdges
+// through the same arguments AddEdge takes.
+type EdgeSpec struct {
+	// Source vertex of the edge.
+	From Vertex
+	// Destination vertex of the edge.
+	To Vertex
+	// Numeric cost of the edge.
+	Cost uint64
+	// Tags of the edge, whose keys must be unique.
+	Tags []Tag
+}
+
+// BatchError is returned by batch operations run with the
+// WithCollectErrors option. It lists every failure of the batch.
+type BatchError struct {
+	// Errors holds one error per failed item, in batch order.
+	Errors []error
+}
+
+// Error joins the messages of all the errors, one per line.
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns all the errors of the batch.
+func (e *BatchError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any error of the batch matches target.
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddEdges adds a batch of edges to the graph, as if
+// AddEdge was called for each of them in order.
+// By default, AddEdges stops at the first invalid edge and
+// returns its error: the edges that precede it are added,
+// the following ones are not.
+// With the WithCollectErrors option, AddEdges adds all the
+// valid edges instead, and it returns a *BatchError listing
+// every invalid one.
+func (g *Graph) AddEdges(edges []EdgeSpec, opts ...Option) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	q := newQuery(opts)
+	var errs []error
+	for i, spec := range edges {
+		err := g.AddEdge(spec.From, spec.To, spec.Cost, spec.Tags...)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("edge %d (%s->%s): %w", i, spec.From.ID, spec.To.ID, err)
+		if !q.collectErrors {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
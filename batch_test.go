@@ -0,0 +1,74 @@
+package cspf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAddEdges(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	batch := []cspf.EdgeSpec{
+		{From: a, To: b, Cost: 1, Tags: []cspf.Tag{tagBlue}},
+		{From: b, To: c, Cost: 1, Tags: []cspf.Tag{tagBlue, tagRed}},
+		{From: c, To: d, Cost: 1},
+		{From: a, To: d, Cost: 1, Tags: []cspf.Tag{tagRed, tagRed}},
+	}
+
+	Convey("Add a valid batch of edges", t, func() {
+		graph := cspf.Graph{}
+		err := graph.AddEdges(batch[:1])
+		So(err, ShouldBeNil)
+		So(graph.PathStrings(a, b), ShouldResemble, []string{"a->b"})
+	})
+
+	Convey("Stop at the first invalid edge by default", t, func() {
+		graph := cspf.Graph{}
+		err := graph.AddEdges(batch)
+		So(errors.Is(err, cspf.ErrDuplicateTagKey), ShouldBeTrue)
+		So(err.Error(), ShouldStartWith, "edge 1 (b->c)")
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+		So(len(graph.VertexSet[c]), ShouldEqual, 0)
+	})
+
+	Convey("Collect all the errors of the batch", t, func() {
+		graph := cspf.Graph{}
+		err := graph.AddEdges(batch, cspf.WithCollectErrors())
+		So(err, ShouldNotBeNil)
+		So(errors.Is(err, cspf.ErrDuplicateTagKey), ShouldBeTrue)
+
+		var batchErr *cspf.BatchError
+		So(errors.As(err, &batchErr), ShouldBeTrue)
+		So(len(batchErr.Errors), ShouldEqual, 2)
+		lines := strings.Split(err.Error(), "\n")
+		So(len(lines), ShouldEqual, 2)
+		So(lines[0], ShouldStartWith, "edge 1 (b->c)")
+		So(lines[1], ShouldStartWith, "edge 3 (a->d)")
+
+		//The valid edges are inserted anyway
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+		So(len(graph.VertexSet[c]), ShouldEqual, 1)
+	})
+
+	Convey("Call AddEdges on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.AddEdges(batch), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
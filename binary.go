@@ -0,0 +1,406 @@
+package cspf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// binaryMagic starts every graph written by WriteTo,
+// followed by the version of the format. Version 2 adds
+// the default tags of the vertices to version 1, which
+// ReadFrom still reads.
+const (
+	binaryMagic   = "CSPF"
+	binaryVersion = 2
+)
+
+// binaryDisabled flags disabled edges.
+const binaryDisabled byte = 1
+
+// binaryChunk bounds the memory ReadFrom allocates ahead of the
+// bytes it actually reads, so that a corrupted length prefix
+// fails at the end of the input instead of exhausting memory.
+const binaryChunk = 64 << 10
+
+// WriteTo writes the graph in a compact binary format that
+// ReadFrom reads back, and returns the number of bytes written.
+// The format is self-describing: strings are length-prefixed
+// and every tag value is written along with its type, so nil,
+// bools, strings and all the int, uint and float types are read
+// back with the same type. Values of any other type make
+// WriteTo fail with ErrUnsupportedTagType.
+// Vertices, edges in insertion order, costs, tags, IDs, the
+// state of the edges and the default tags of the vertices
+// are preserved.
+func (g *Graph) WriteTo(w io.Writer) (int64, error) {
+	if g == nil {
+		return 0, ErrNilGraph
+	}
+	bw := &binaryWriter{w: bufio.NewWriter(w)}
+	bw.bytes([]byte(binaryMagic))
+	bw.byte(binaryVersion)
+
+	vertices := g.Vertices()
+	index := make(map[Vertex]uint64, len(vertices))
+	for i, v := range vertices {
+		index[v] = uint64(i)
+	}
+	bw.uvarint(uint64(len(vertices)))
+	for _, v := range vertices {
+		bw.string(v.ID)
+	}
+	bw.uvarint(uint64(g.EdgeCount()))
+	for _, v := range vertices {
+		for _, edge := range g.VertexSet[v] {
+			to, ok := index[edge.To]
+			if !ok {
+				return bw.n, fmt.Errorf("%w: vertex %s is not part of the graph", ErrInvalidEncoding, edge.To.ID)
+			}
+			bw.uvarint(index[v])
+			bw.uvarint(to)
+			bw.uvarint(edge.Cost)
+			bw.string(edge.ID)
+			var flags byte
+			if edge.Disabled {
+				flags |= binaryDisabled
+			}
+			bw.byte(flags)
+			if err := bw.tags(edge.Tags); err != nil {
+				return bw.n, fmt.Errorf("%w of edge %s->%s", err, edge.From.ID, edge.To.ID)
+			}
+		}
+	}
+	var tagged []Vertex
+	for _, v := range vertices {
+		if len(g.vertexTags[v]) > 0 {
+			tagged = append(tagged, v)
+		}
+	}
+	bw.uvarint(uint64(len(tagged)))
+	for _, v := range tagged {
+		bw.uvarint(index[v])
+		if err := bw.tags(g.vertexTags[v]); err != nil {
+			return bw.n, fmt.Errorf("%w of vertex %s", err, v.ID)
+		}
+	}
+	if bw.err != nil {
+		return bw.n, bw.err
+	}
+	return bw.n, bw.w.Flush()
+}
+
+// ReadFrom replaces the content of the graph, including the
+// default tags of its vertices, with a graph written by WriteTo,
+// and returns the number of bytes read.
+// Input that is not a valid binary graph makes ReadFrom fail
+// with ErrInvalidEncoding, leaving the graph unchanged.
+// The input is buffered, so ReadFrom may read past the end
+// of the graph.
+func (g *Graph) ReadFrom(r io.Reader) (int64, error) {
+	if g == nil {
+		return 0, ErrNilGraph
+	}
+	br := &binaryReader{r: bufio.NewReader(r)}
+	magic := br.bytes(len(binaryMagic) + 1)
+	var version byte
+	if br.err == nil {
+		version = magic[len(binaryMagic)]
+		if string(magic[:len(binaryMagic)]) != binaryMagic || version < 1 || version > binaryVersion {
+			return br.n, fmt.Errorf("%w: unknown format", ErrInvalidEncoding)
+		}
+	}
+
+	read := Graph{}
+	vertexCount := br.uvarint()
+	var vertices []Vertex
+	for i := uint64(0); i < vertexCount && br.err == nil; i++ {
+		v := Vertex{ID: br.string()}
+		vertices = append(vertices, v)
+		read.addNode(v)
+	}
+	edgeCount := br.uvarint()
+	for i := uint64(0); i < edgeCount && br.err == nil; i++ {
+		from, to := br.uvarint(), br.uvarint()
+		if br.err == nil && (from >= uint64(len(vertices)) || to >= uint64(len(vertices))) {
+			return br.n, fmt.Errorf("%w: vertex index out of range", ErrInvalidEncoding)
+		}
+		edge := Edge{Cost: br.uvarint(), ID: br.string()}
+		if flags := br.bytes(1); br.err == nil {
+			edge.Disabled = flags[0]&binaryDisabled != 0
+		}
+		edge.Tags = br.tags()
+		if br.err == nil {
+			edge.From, edge.To = vertices[from], vertices[to]
+			read.addEdge(edge)
+		}
+	}
+	if version >= 2 {
+		taggedCount := br.uvarint()
+		for i := uint64(0); i < taggedCount && br.err == nil; i++ {
+			v := br.uvarint()
+			if br.err == nil && v >= uint64(len(vertices)) {
+				return br.n, fmt.Errorf("%w: vertex index out of range", ErrInvalidEncoding)
+			}
+			for key, value := range br.tags() {
+				read.SetVertexDefaultTag(vertices[v], key, value)
+			}
+		}
+	}
+	if br.err != nil {
+		return br.n, fmt.Errorf("%w: %v", ErrInvalidEncoding, br.err)
+	}
+	g.VertexSet = read.VertexSet
+	g.vertexTags = read.vertexTags
+	return br.n, nil
+}
+
+// binaryWriter writes the primitives of the binary format,
+// keeping track of the bytes written and of the first error.
+type binaryWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (bw *binaryWriter) bytes(b []byte) {
+	if bw.err != nil {
+		return
+	}
+	n, err := bw.w.Write(b)
+	bw.n += int64(n)
+	bw.err = err
+}
+
+func (bw *binaryWriter) byte(b byte) {
+	if bw.err != nil {
+		return
+	}
+	bw.err = bw.w.WriteByte(b)
+	bw.n++
+}
+
+func (bw *binaryWriter) uvarint(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	bw.bytes(buf[:binary.PutUvarint(buf[:], x)])
+}
+
+func (bw *binaryWriter) varint(x int64) {
+	var buf [binary.MaxVarintLen64]byte
+	bw.bytes(buf[:binary.PutVarint(buf[:], x)])
+}
+
+func (bw *binaryWriter) string(s string) {
+	bw.uvarint(uint64(len(s)))
+	if bw.err != nil {
+		return
+	}
+	n, err := bw.w.WriteString(s)
+	bw.n += int64(n)
+	bw.err = err
+}
+
+func (bw *binaryWriter) fixed(x uint64, size int) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	bw.bytes(buf[:size])
+}
+
+// tags writes a set of tags, sorted by key,
+// preceded by their number.
+func (bw *binaryWriter) tags(tags map[string]interface{}) error {
+	bw.uvarint(uint64(len(tags)))
+	for _, key := range sortedKeys(tags) {
+		bw.string(key)
+		if err := bw.value(tags[key]); err != nil {
+			return fmt.Errorf("%w: tag %s", err, key)
+		}
+	}
+	return nil
+}
+
+// value writes a tag value preceded by its type.
+func (bw *binaryWriter) value(value interface{}) error {
+	t, err := typeOfTag(value)
+	if err != nil {
+		return err
+	}
+	bw.byte(byte(t))
+	switch v := value.(type) {
+	case bool:
+		b := byte(0)
+		if v {
+			b = 1
+		}
+		bw.byte(b)
+	case string:
+		bw.string(v)
+	case int:
+		bw.varint(int64(v))
+	case int8:
+		bw.varint(int64(v))
+	case int16:
+		bw.varint(int64(v))
+	case int32:
+		bw.varint(int64(v))
+	case int64:
+		bw.varint(v)
+	case uint:
+		bw.uvarint(uint64(v))
+	case uint8:
+		bw.uvarint(uint64(v))
+	case uint16:
+		bw.uvarint(uint64(v))
+	case uint32:
+		bw.uvarint(uint64(v))
+	case uint64:
+		bw.uvarint(v)
+	case float32:
+		bw.fixed(uint64(math.Float32bits(v)), 4)
+	case float64:
+		bw.fixed(math.Float64bits(v), 8)
+	}
+	return nil
+}
+
+// binaryReader reads the primitives of the binary format,
+// keeping track of the bytes read and of the first error.
+type binaryReader struct {
+	r   *bufio.Reader
+	n   int64
+	err error
+}
+
+// ReadByte lets binary.ReadUvarint count the bytes it reads.
+func (br *binaryReader) ReadByte() (byte, error) {
+	b, err := br.r.ReadByte()
+	if err == nil {
+		br.n++
+	}
+	return b, err
+}
+
+// bytes reads the given number of bytes, growing the buffer
+// by at most binaryChunk bytes at a time.
+func (br *binaryReader) bytes(size int) []byte {
+	if br.err != nil {
+		return nil
+	}
+	b := []byte{}
+	for len(b) < size {
+		start := len(b)
+		chunk := size - start
+		if chunk > binaryChunk {
+			chunk = binaryChunk
+		}
+		b = append(b, make([]byte, chunk)...)
+		n, err := io.ReadFull(br.r, b[start:])
+		br.n += int64(n)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			br.err = err
+			return b[:start+n]
+		}
+	}
+	return b
+}
+
+func (br *binaryReader) uvarint() uint64 {
+	if br.err != nil {
+		return 0
+	}
+	x, err := binary.ReadUvarint(br)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	br.err = err
+	return x
+}
+
+func (br *binaryReader) varint() int64 {
+	if br.err != nil {
+		return 0
+	}
+	x, err := binary.ReadVarint(br)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	br.err = err
+	return x
+}
+
+func (br *binaryReader) string() string {
+	size := br.uvarint()
+	if br.err == nil && size > math.MaxInt32 {
+		br.err = fmt.Errorf("string too long")
+	}
+	if br.err != nil {
+		return ""
+	}
+	return string(br.bytes(int(size)))
+}
+
+func (br *binaryReader) fixed(size int) uint64 {
+	var buf [8]byte
+	copy(buf[:], br.bytes(size))
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// tags reads a set of tags preceded by their number,
+// or nil if there is none.
+func (br *binaryReader) tags() map[string]interface{} {
+	var tags map[string]interface{}
+	count := br.uvarint()
+	for i := uint64(0); i < count && br.err == nil; i++ {
+		if tags == nil {
+			tags = make(map[string]interface{})
+		}
+		key := br.string()
+		if _, ok := tags[key]; ok && br.err == nil {
+			br.err = fmt.Errorf("duplicate tag %s", key)
+			break
+		}
+		tags[key] = br.value()
+	}
+	return tags
+}
+
+// value reads a tag value preceded by its type.
+func (br *binaryReader) value() interface{} {
+	kind := br.bytes(1)
+	if br.err != nil {
+		return nil
+	}
+	switch t := tagType(kind[0]); t {
+	case tagNil:
+		return nil
+	case tagBool:
+		b := br.bytes(1)
+		return br.err == nil && b[0] != 0
+	case tagString:
+		return br.string()
+	case tagInt, tagInt8, tagInt16, tagInt32, tagInt64:
+		i := br.varint()
+		//Out-of-range values are rejected like UnmarshalJSON does
+		if bits := intBits(t); br.err == nil && bits < 64 && (i < -1<<(bits-1) || i >= 1<<(bits-1)) {
+			br.err = fmt.Errorf("%s tag %d out of range", t, i)
+		}
+		return intTag(t, i)
+	case tagUint, tagUint8, tagUint16, tagUint32, tagUint64:
+		u := br.uvarint()
+		if bits := intBits(t); br.err == nil && bits < 64 && u >= 1<<bits {
+			br.err = fmt.Errorf("%s tag %d out of range", t, u)
+		}
+		return uintTag(t, u)
+	case tagFloat32:
+		return math.Float32frombits(uint32(br.fixed(4)))
+	case tagFloat64:
+		return math.Float64frombits(br.fixed(8))
+	}
+	br.err = fmt.Errorf("unknown tag type %d", kind[0])
+	return nil
+}
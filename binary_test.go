@@ -0,0 +1,173 @@
+package cspf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with all the supported tag types", t, func() {
+		So(graph.AddEdge(a, b, 1,
+			cspf.Tag{Key: "nil", Value: nil},
+			cspf.Tag{Key: "bool", Value: true},
+			cspf.Tag{Key: "string", Value: "red"},
+			cspf.Tag{Key: "int", Value: -1},
+			cspf.Tag{Key: "int8", Value: int8(-8)},
+			cspf.Tag{Key: "int16", Value: int16(-16)},
+			cspf.Tag{Key: "int32", Value: int32(-32)},
+			cspf.Tag{Key: "int64", Value: int64(-64)},
+		), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1<<40,
+			cspf.Tag{Key: "uint", Value: uint(1)},
+			cspf.Tag{Key: "uint8", Value: uint8(8)},
+			cspf.Tag{Key: "uint16", Value: uint16(16)},
+			cspf.Tag{Key: "uint32", Value: uint32(32)},
+			cspf.Tag{Key: "uint64", Value: uint64(1 << 63)},
+			cspf.Tag{Key: "float32", Value: float32(0.5)},
+			cspf.Tag{Key: "float64", Value: 1.25},
+		), ShouldBeNil)
+		So(graph.AddEdgeWithID("parallel", a, b, 2), ShouldBeNil)
+		So(graph.SetEdgeEnabled(b, c, false), ShouldEqual, 1)
+		graph.AddNode(cspf.Vertex{ID: "isolated"})
+	})
+
+	Convey("WriteTo then ReadFrom produces an equal graph", t, func() {
+		var buf bytes.Buffer
+		written, err := graph.WriteTo(&buf)
+		So(err, ShouldBeNil)
+		So(written, ShouldEqual, buf.Len())
+
+		read := cspf.Graph{}
+		n, err := read.ReadFrom(&buf)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, written)
+		So(read.Equal(&graph), ShouldBeTrue)
+		So(read.VertexSet[a], ShouldResemble, graph.VertexSet[a])
+		So(read.VertexSet[b], ShouldResemble, graph.VertexSet[b])
+	})
+
+	Convey("Graphs written in version 1 of the format are read", t, func() {
+		var buf bytes.Buffer
+		_, err := graph.WriteTo(&buf)
+		So(err, ShouldBeNil)
+		//Version 1 ends before the count of the tagged vertices
+		encoded := buf.Bytes()
+		v1 := append([]byte("CSPF\x01"), encoded[5:len(encoded)-1]...)
+		read := cspf.Graph{}
+		_, err = read.ReadFrom(bytes.NewReader(v1))
+		So(err, ShouldBeNil)
+		So(read.Equal(&graph), ShouldBeTrue)
+	})
+
+	Convey("Unsupported tag types are reported", t, func() {
+		unsupported := cspf.Graph{}
+		So(unsupported.AddEdge(a, b, 1, cspf.Tag{Key: "list", Value: []int{1}}), ShouldBeNil)
+		_, err := unsupported.WriteTo(&bytes.Buffer{})
+		So(errors.Is(err, cspf.ErrUnsupportedTagType), ShouldBeTrue)
+	})
+
+	Convey("Invalid input leaves the graph unchanged", t, func() {
+		var buf bytes.Buffer
+		_, err := graph.WriteTo(&buf)
+		So(err, ShouldBeNil)
+		encoded := buf.Bytes()
+		for _, input := range [][]byte{nil, []byte("JSON"), encoded[:len(encoded)-1]} {
+			read := cspf.Graph{}
+			So(read.AddEdge(a, c, 1), ShouldBeNil)
+			_, err := read.ReadFrom(bytes.NewReader(input))
+			So(errors.Is(err, cspf.ErrInvalidEncoding), ShouldBeTrue)
+			So(read.EdgeCount(), ShouldEqual, 1)
+		}
+	})
+
+	Convey("Corrupted input is rejected", t, func() {
+		header := "CSPF\x02\x02\x01a\x01b\x01\x00\x01\x01\x00\x00"
+		for _, input := range []string{
+			//A string length prefix of about 2^31 bytes
+			"CSPF\x02\x01\xff\xff\xff\xff\x07a",
+			//An int8 tag of 300
+			header + "\x01\x01k\x04\xd8\x04\x00",
+			//A uint8 tag of 256
+			header + "\x01\x01k\x09\x80\x02\x00",
+			//The same tag key twice
+			header + "\x02\x01k\x00\x01k\x00\x00",
+		} {
+			read := cspf.Graph{}
+			_, err := read.ReadFrom(strings.NewReader(input))
+			So(errors.Is(err, cspf.ErrInvalidEncoding), ShouldBeTrue)
+		}
+		//The header alone makes a valid graph
+		read := cspf.Graph{}
+		_, err := read.ReadFrom(strings.NewReader(header + "\x00\x00"))
+		So(err, ShouldBeNil)
+		So(read.EdgeCount(), ShouldEqual, 1)
+	})
+
+	Convey("Call WriteTo and ReadFrom on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.WriteTo(&bytes.Buffer{})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+		_, err = nilGraph.ReadFrom(&bytes.Buffer{})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+// generateTaggedGraph builds a graph with n vertices and
+// about 10 edges per vertex, each carrying a few tags.
+func generateTaggedGraph(n int) *cspf.Graph {
+	graph := cspf.Graph{}
+	for i := 0; i < n; i++ {
+		from := cspf.Vertex{ID: fmt.Sprintf("router-%d", i)}
+		for j := 1; j <= 10; j++ {
+			to := cspf.Vertex{ID: fmt.Sprintf("router-%d", (i+j*j)%n)}
+			graph.AddEdge(from, to, uint64(i*j%100),
+				cspf.Tag{Key: "link", Value: "blue"},
+				cspf.Tag{Key: "latency", Value: float64(j) / 10},
+				cspf.Tag{Key: "bandwidth", Value: j * 1000},
+			)
+		}
+	}
+	return &graph
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	graph := generateTaggedGraph(10000)
+	var buf bytes.Buffer
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if _, err := graph.WriteTo(&buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(buf.Len()), "bytes")
+}
+
+func BenchmarkReadFrom(b *testing.B) {
+	var buf bytes.Buffer
+	if _, err := generateTaggedGraph(10000).WriteTo(&buf); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		graph := cspf.Graph{}
+		if _, err := graph.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
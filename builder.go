@@ -0,0 +1,120 @@
+package cspf
+
+import "strings"
+
+// QueryBuilder accumulates the settings of a shortest-path query
+// through chainable methods, and runs the query with Run, e.g.
+//
+//	g.Query().From(a).To(e).Where(`link == "blue"`).MaxHops(4).Run()
+//
+// A QueryBuilder is created by the Query method of a graph.
+type QueryBuilder struct {
+	graph   *Graph
+	from    Vertex
+	to      Vertex
+	exps    []string
+	maxHops int
+	avoid   []Edge
+	opts    []Option
+}
+
+// Query returns a QueryBuilder for a query on the graph.
+func (g *Graph) Query() *QueryBuilder {
+	return &QueryBuilder{graph: g}
+}
+
+// From sets the source vertex of the query.
+func (b *QueryBuilder) From(v Vertex) *QueryBuilder {
+	b.from = v
+	return b
+}
+
+// To sets the destination vertex of the query.
+func (b *QueryBuilder) To(v Vertex) *QueryBuilder {
+	b.to = v
+	return b
+}
+
+// Where constrains the query to the edges that satisfy the
+// expression, as CSPF does. Expressions given by several calls
+// must all be satisfied.
+func (b *QueryBuilder) Where(exp string) *QueryBuilder {
+	b.exps = append(b.exps, exp)
+	return b
+}
+
+// MaxHops limits the paths of the query to the given number
+// of edges. Zero or a negative limit means no limit.
+func (b *QueryBuilder) MaxHops(hops int) *QueryBuilder {
+	b.maxHops = hops
+	return b
+}
+
+// Avoid excludes the given edges from the query, as
+// RerouteAvoiding does. Edges given by several calls
+// are all excluded.
+func (b *QueryBuilder) Avoid(edges ...Edge) *QueryBuilder {
+	b.avoid = append(b.avoid, edges...)
+	return b
+}
+
+// With adds options to the query, such as WithSinglePath
+// or WithFunction.
+func (b *QueryBuilder) With(opts ...Option) *QueryBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Run runs the query and returns its result graph.
+// Without a hop limit, it is the same result graph as SPF or
+// CSPF, with all the shortest paths of equal cost unless the
+// WithSinglePath option is given. With a hop limit, the search
+// runs on (vertex, hops so far) states and the result graph
+// contains a single cheapest path among those with at most as
+// many edges, or it is empty if there is none.
+func (b *QueryBuilder) Run() (*Graph, error) {
+	if b.graph == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(b.opts)
+	if len(b.exps) > 0 {
+		exp := b.exps[0]
+		if len(b.exps) > 1 {
+			exp = "(" + strings.Join(b.exps, ") && (") + ")"
+		}
+		err := b.graph.compile(q, exp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(b.avoid) > 0 {
+		avoid := b.avoid
+		q.filters = append(q.filters, func(e Edge) bool {
+			return !containsEdge(avoid, e)
+		})
+	}
+	if b.maxHops <= 0 {
+		return b.graph.spf(b.from, b.to, q)
+	}
+	//Among the cheapest walks within the hop limit the search
+	//settles the one with the fewest hops first, which is always
+	//a simple path, so it can be returned as a result graph
+	maxHops := b.maxHops
+	path, err := b.graph.stateSearch(b.from, b.to, q, func(hops int, ref edgeRef, edge Edge) (int, bool, error) {
+		if hops == maxHops {
+			return 0, false, nil
+		}
+		satisfied, err := q.edgeSatisfiesConstranints(ref, edge)
+		return hops + 1, satisfied, err
+	}, func(int) bool {
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := Graph{}
+	for _, edge := range path {
+		result.addEdge(edge)
+	}
+	return &result, nil
+}
@@ -0,0 +1,110 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+	tagRed := cspf.Tag{Key: "link", Value: "red"}
+	fast := cspf.Tag{Key: "fast", Value: true}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		//a -> b -> c -> d -> e is blue and cheap,
+		//a -> e is red and expensive,
+		//a -> c -> e is blue with a fast first hop
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(d, e, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, e, 10, tagRed), ShouldBeNil)
+		So(graph.AddEdge(a, c, 4, tagBlue, fast), ShouldBeNil)
+		So(graph.AddEdge(c, e, 3, tagBlue), ShouldBeNil)
+	})
+
+	paths := func(result *cspf.Graph) []string {
+		list := []string{}
+		for _, path := range result.PathList(a, e) {
+			list = append(list, path.String())
+		}
+		return list
+	}
+
+	Convey("No modifiers runs SPF", t, func() {
+		result, err := graph.Query().From(a).To(e).Run()
+		So(err, ShouldBeNil)
+		spf, _ := graph.SPF(a, e)
+		So(result.Equal(spf), ShouldBeTrue)
+		So(paths(result), ShouldResemble, []string{"a->b->c->d->e"})
+	})
+
+	Convey("Where runs CSPF", t, func() {
+		result, err := graph.Query().From(a).To(e).Where(`link == "red"`).Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->e"})
+	})
+
+	Convey("Several Where calls must all hold", t, func() {
+		result, err := graph.Query().From(a).Where(`link == "blue"`).Where(`link != "red"`).To(e).Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->b->c->d->e"})
+	})
+
+	Convey("MaxHops limits the length of the path", t, func() {
+		result, err := graph.Query().From(a).To(e).MaxHops(3).Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->b->c->e"})
+		result, err = graph.Query().From(a).To(e).MaxHops(1).Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->e"})
+	})
+
+	Convey("Where and MaxHops combine", t, func() {
+		result, err := graph.Query().From(a).To(e).Where(`link == "blue"`).MaxHops(4).Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->b->c->d->e"})
+		result, err = graph.Query().From(a).To(e).Where(`link == "blue"`).MaxHops(1).Run()
+		So(err, ShouldBeNil)
+		So(result.VertexSet, ShouldBeEmpty)
+	})
+
+	Convey("Avoid and With combine with the other modifiers", t, func() {
+		result, err := graph.Query().From(a).To(e).
+			Avoid(cspf.Edge{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"link": "blue"}}).
+			Where(`link == "blue"`).
+			With(cspf.WithSinglePath()).
+			Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->c->d->e"})
+
+		result, err = graph.Query().From(a).To(e).
+			Where(`fast == true || isRed(link)`).
+			With(cspf.WithFunction("isRed", func(link string) bool { return link == "red" })).
+			MaxHops(2).
+			Run()
+		So(err, ShouldBeNil)
+		So(paths(result), ShouldResemble, []string{"a->e"})
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		_, err := graph.Query().From(a).To(e).Where(`link ==`).Run()
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Run on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.Query().From(a).To(e).Run()
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
@@ -0,0 +1,173 @@
+package cspf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// tagsString serializes a set of tags deterministically,
+// sorting them by key. Values are rendered along with
+// their type, so that e.g. 1 and "1" are told apart.
+func tagsString(tags map[string]interface{}) string {
+	var b strings.Builder
+	for i, key := range sortedKeys(tags) {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%q=%T(%#v)", key, tags[key], tags[key])
+	}
+	return b.String()
+}
+
+// equalTags reports whether two sets of tags have the
+// same keys with deeply equal values.
+func equalTags(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		otherValue, ok := b[key]
+		if !ok || !reflect.DeepEqual(value, otherValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a set of tags, sorted.
+func sortedKeys(tags map[string]interface{}) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lessEdge orders edges by source ID, destination ID,
+// cost, their serialized tags and then enabled first.
+func lessEdge(a, b Edge) bool {
+	if a.From.ID != b.From.ID {
+		return a.From.ID < b.From.ID
+	}
+	if a.To.ID != b.To.ID {
+		return a.To.ID < b.To.ID
+	}
+	if a.Cost != b.Cost {
+		return a.Cost < b.Cost
+	}
+	if tagsA, tagsB := tagsString(a.Tags), tagsString(b.Tags); tagsA != tagsB {
+		return tagsA < tagsB
+	}
+	return !a.Disabled && b.Disabled
+}
+
+// sortedEdges returns a sorted copy of the given edges.
+func sortedEdges(edges []Edge) []Edge {
+	sorted := append([]Edge(nil), edges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return lessEdge(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// CanonicalEdges returns all the edges of the graph in a fully
+// deterministic order, regardless of the insertion order: by
+// source ID, destination ID, cost, tags serialized with their
+// keys sorted, and then enabled first.
+// It is the order Equal, Canonical and Fingerprint rely on.
+func (g *Graph) CanonicalEdges() []Edge {
+	if g == nil {
+		return nil
+	}
+	edges := make([]Edge, 0, g.EdgeCount())
+	//Vertex IDs are unique, so sorting the edges of every
+	//vertex on its own sorts all of them
+	for _, v := range g.Vertices() {
+		edges = append(edges, sortedEdges(g.VertexSet[v])...)
+	}
+	return edges
+}
+
+// Equal reports whether two graphs are structurally equal:
+// they have the same vertices with the same default tags, and
+// the same edges with the same costs, tags and state, regardless
+// of the insertion order.
+// Parallel edges are compared as a multiset.
+// A nil graph is only equal to another nil graph.
+func (g *Graph) Equal(other *Graph) bool {
+	if g == nil || other == nil {
+		return g == other
+	}
+	if len(g.VertexSet) != len(other.VertexSet) {
+		return false
+	}
+	for v := range g.VertexSet {
+		if _, ok := other.VertexSet[v]; !ok {
+			return false
+		}
+		if !equalTags(g.vertexTags[v], other.vertexTags[v]) {
+			return false
+		}
+	}
+	edges, otherEdges := g.CanonicalEdges(), other.CanonicalEdges()
+	if len(edges) != len(otherEdges) {
+		return false
+	}
+	for i := range edges {
+		if !edges[i].equal(otherEdges[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Canonical renders the structure of the graph as a
+// deterministic multi-line listing, suitable for golden-file
+// tests: every vertex, sorted by ID, is followed by its default
+// tags, if any, and by its edges in canonical order, with their
+// costs, tags sorted by key, and state. Graphs that are Equal
+// have the same listing.
+//
+//	vertex "A"
+//	default "area"=int(1)
+//	edge "A" "B" 1 "link"=string("red")
+//	vertex "B"
+func (g *Graph) Canonical() string {
+	var b strings.Builder
+	edges := g.CanonicalEdges()
+	for _, v := range g.Vertices() {
+		fmt.Fprintf(&b, "vertex %q\n", v.ID)
+		if defaults := g.vertexTags[v]; len(defaults) > 0 {
+			fmt.Fprintf(&b, "default %s\n", tagsString(defaults))
+		}
+		for ; len(edges) > 0 && edges[0].From == v; edges = edges[1:] {
+			edge := edges[0]
+			fmt.Fprintf(&b, "edge %q %q %d", edge.From.ID, edge.To.ID, edge.Cost)
+			if len(edge.Tags) > 0 {
+				b.WriteString(" " + tagsString(edge.Tags))
+			}
+			if edge.Disabled {
+				b.WriteString(" disabled")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Fingerprint computes a SHA-256 hash of the structure of
+// the graph, as a hex string: the Canonical listing of the
+// graph is hashed, so that graphs that are Equal produce the
+// same fingerprint regardless of their insertion order. Any
+// change to the structure changes the fingerprint, which
+// makes it suitable for change detection.
+// Tag values are hashed through their Go syntax representation.
+func (g *Graph) Fingerprint() string {
+	hash := sha256.Sum256([]byte(g.Canonical()))
+	return hex.EncodeToString(hash[:])
+}
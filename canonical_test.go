@@ -0,0 +1,166 @@
+package cspf_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEqualAndFingerprint(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagWeight := cspf.Tag{
+		Key:   "weight",
+		Value: 1,
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	first := cspf.Graph{}
+	second := cspf.Graph{}
+
+	Convey("Populate two graphs in different orders", t, func() {
+		So(first.AddEdge(a, b, 1, tagBlue, tagWeight), ShouldBeNil)
+		So(first.AddEdge(a, c, 2), ShouldBeNil)
+		So(first.AddEdge(b, c, 3, tagBlue), ShouldBeNil)
+		first.AddNode(cspf.Vertex{ID: "d"})
+
+		second.AddNode(cspf.Vertex{ID: "d"})
+		So(second.AddEdge(b, c, 3, tagBlue), ShouldBeNil)
+		So(second.AddEdge(a, c, 2), ShouldBeNil)
+		So(second.AddEdge(a, b, 1, tagWeight, tagBlue), ShouldBeNil)
+	})
+
+	Convey("Equal graphs have the same fingerprint", t, func() {
+		So(first.Equal(&second), ShouldBeTrue)
+		So(second.Equal(&first), ShouldBeTrue)
+		So(first.Fingerprint(), ShouldEqual, second.Fingerprint())
+		So(len(first.Fingerprint()), ShouldEqual, 64)
+	})
+
+	Convey("A cost change alters the fingerprint", t, func() {
+		changed := cspf.Graph{}
+		So(changed.AddEdge(a, b, 1, tagBlue, tagWeight), ShouldBeNil)
+		So(changed.AddEdge(a, c, 5), ShouldBeNil)
+		So(changed.AddEdge(b, c, 3, tagBlue), ShouldBeNil)
+		changed.AddNode(cspf.Vertex{ID: "d"})
+		So(first.Equal(&changed), ShouldBeFalse)
+		So(first.Fingerprint(), ShouldNotEqual, changed.Fingerprint())
+	})
+
+	Convey("A tag value of a different type alters the fingerprint", t, func() {
+		changed := cspf.Graph{}
+		So(changed.AddEdge(a, b, 1, tagBlue, cspf.Tag{Key: "weight", Value: "1"}), ShouldBeNil)
+		So(changed.AddEdge(a, c, 2), ShouldBeNil)
+		So(changed.AddEdge(b, c, 3, tagBlue), ShouldBeNil)
+		changed.AddNode(cspf.Vertex{ID: "d"})
+		So(first.Equal(&changed), ShouldBeFalse)
+		So(first.Fingerprint(), ShouldNotEqual, changed.Fingerprint())
+	})
+
+	Convey("Compare nil graphs", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Equal(nil), ShouldBeTrue)
+		So(nilGraph.Equal(&first), ShouldBeFalse)
+		So(first.Equal(nilGraph), ShouldBeFalse)
+		So(nilGraph.Fingerprint(), ShouldEqual, (&cspf.Graph{}).Fingerprint())
+	})
+}
+
+func TestCanonical(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+	tagWeight := cspf.Tag{Key: "weight", Value: 1}
+
+	first := cspf.Graph{}
+	second := cspf.Graph{}
+
+	Convey("Populate two graphs in different orders", t, func() {
+		So(first.AddEdge(a, b, 1, tagBlue, tagWeight), ShouldBeNil)
+		So(first.AddEdge(a, b, 1), ShouldBeNil)
+		So(first.AddEdge(b, c, 3), ShouldBeNil)
+		So(first.SetEdgeEnabled(b, c, false), ShouldEqual, 1)
+		first.AddNode(cspf.Vertex{ID: "d"})
+
+		second.AddNode(cspf.Vertex{ID: "d"})
+		So(second.AddEdge(b, c, 3), ShouldBeNil)
+		So(second.SetEdgeEnabled(b, c, false), ShouldEqual, 1)
+		So(second.AddEdge(a, b, 1), ShouldBeNil)
+		So(second.AddEdge(a, b, 1, tagWeight, tagBlue), ShouldBeNil)
+	})
+
+	Convey("Equal graphs have the same listing", t, func() {
+		So(first.Canonical(), ShouldEqual, second.Canonical())
+		So(first.Canonical(), ShouldEqual, `vertex "a"
+edge "a" "b" 1
+edge "a" "b" 1 "link"=string("blue"),"weight"=int(1)
+vertex "b"
+edge "b" "c" 3 disabled
+vertex "c"
+vertex "d"
+`)
+	})
+
+	Convey("A cost change alters the listing", t, func() {
+		So(second.UpdateEdgeCost(b, c, 4), ShouldEqual, 1)
+		So(first.Canonical(), ShouldNotEqual, second.Canonical())
+	})
+
+	Convey("Call Canonical on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Canonical(), ShouldEqual, "")
+	})
+}
+
+func TestCanonicalEdges(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	edges := []cspf.Edge{
+		{From: a, To: b, Cost: 2},
+		{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"link": "red"}},
+		{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"link": "blue"}},
+		{From: a, To: c, Cost: 1},
+		{From: b, To: a, Cost: 1},
+		{From: c, To: a, Cost: 7},
+	}
+
+	Convey("The order does not depend on the insertion order", t, func() {
+		rng := rand.New(rand.NewSource(1))
+		var first []cspf.Edge
+		for run := 0; run < 50; run++ {
+			graph := cspf.Graph{}
+			for _, i := range rng.Perm(len(edges)) {
+				tags := []cspf.Tag{}
+				for key, value := range edges[i].Tags {
+					tags = append(tags, cspf.Tag{Key: key, Value: value})
+				}
+				So(graph.AddEdge(edges[i].From, edges[i].To, edges[i].Cost, tags...), ShouldBeNil)
+			}
+			canonical := graph.CanonicalEdges()
+			if first == nil {
+				first = canonical
+			}
+			So(canonical, ShouldResemble, first)
+		}
+		So(len(first), ShouldEqual, len(edges))
+		So(first[0].Tags["link"], ShouldEqual, "blue")
+		So(first[1].Tags["link"], ShouldEqual, "red")
+		So(first[2].Cost, ShouldEqual, 2)
+		So(first[5].From, ShouldResemble, c)
+	})
+
+	Convey("Call CanonicalEdges on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.CanonicalEdges(), ShouldBeNil)
+	})
+}
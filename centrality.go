@@ -0,0 +1,223 @@
+package cspf
+
+// EdgeScore pairs an edge of the graph with a numeric score.
+type EdgeScore struct {
+	// Edge is the scored edge.
+	Edge Edge
+	// Score is the value computed for the edge.
+	Score float64
+}
+
+// edgeRef identifies an edge by its source vertex and
+// its position in the source's list of edges. Unlike Edge,
+// it can be used as a map key and it tells parallel
+// edges apart.
+type edgeRef struct {
+	from  Vertex
+	index int
+}
+
+// brandesSource holds the result of one single-source
+// pass of the Brandes algorithm.
+type brandesSource struct {
+	// Settled vertices in non-decreasing distance order.
+	order []Vertex
+	// Number of shortest paths from the source to each vertex.
+	sigma map[Vertex]float64
+	// Predecessor edges of each vertex on its shortest paths.
+	preds map[Vertex][]edgeRef
+}
+
+// brandesPass runs Dijkstra from the given source, counting
+// the shortest paths that reach every vertex over enabled edges.
+// Path counts are float64 on purpose: on dense graphs or long
+// chains of equal-cost alternatives the number of shortest
+// paths grows exponentially and would overflow any integer type.
+func (g *Graph) brandesPass(source Vertex) brandesSource {
+	res := brandesSource{
+		sigma: map[Vertex]float64{source: 1},
+		preds: make(map[Vertex][]edgeRef),
+	}
+	dist := map[Vertex]uint64{source: 0}
+	settled := make(map[Vertex]bool)
+	queue := vertexQueue{}
+	queue.push(source, 0)
+
+	for queue.Len() > 0 {
+		item := queue.pop()
+		v := item.vertex
+		if settled[v] || item.dist != dist[v] {
+			continue
+		}
+		settled[v] = true
+		res.order = append(res.order, v)
+
+		for i, edge := range g.VertexSet[v] {
+			w := edge.To
+			if edge.Disabled || w == v || settled[w] {
+				continue
+			}
+			alt := addCost(dist[v], edge.Cost)
+			if alt == infinity {
+				continue
+			}
+			d, seen := dist[w]
+			switch {
+			case !seen || alt < d:
+				dist[w] = alt
+				res.sigma[w] = res.sigma[v]
+				res.preds[w] = []edgeRef{{from: v, index: i}}
+				queue.push(w, alt)
+			case alt == d:
+				res.sigma[w] += res.sigma[v]
+				res.preds[w] = append(res.preds[w], edgeRef{from: v, index: i})
+			}
+		}
+	}
+	return res
+}
+
+// EdgeBetweenness computes the betweenness centrality of
+// every edge: for each ordered pair of distinct vertices
+// (s, t), an edge is credited with the fraction of the
+// shortest paths from s to t that traverse it.
+// Equal-cost paths share the credit proportionally.
+//
+// Scores are accumulated as float64 values, as are the
+// shortest-path counts they are derived from. Counts are
+// exact up to 2^53 paths per pair; above that they are
+// approximated with a relative error in the order of 1e-16,
+// which makes the scores reliable well beyond the point
+// where integer counters would overflow.
+//
+// The result lists all the edges of the graph, sorted by
+// source vertex ID and then by insertion order. Disabled
+// edges are never traversed, so they score zero.
+func (g *Graph) EdgeBetweenness() []EdgeScore {
+	if g == nil {
+		return nil
+	}
+	scores := make(map[edgeRef]float64)
+	for source := range g.VertexSet {
+		g.accumulateBetweenness(source, scores, nil)
+	}
+
+	result := make([]EdgeScore, 0, len(scores))
+	for _, v := range g.Vertices() {
+		for i, edge := range g.VertexSet[v] {
+			result = append(result, EdgeScore{
+				Edge:  edge,
+				Score: scores[edgeRef{from: v, index: i}],
+			})
+		}
+	}
+	return result
+}
+
+// accumulateBetweenness runs one Brandes pass from source and adds
+// the dependencies of the source to the given edge scores and
+// vertex scores, either of which can be nil.
+func (g *Graph) accumulateBetweenness(source Vertex, edgeScores map[edgeRef]float64, vertexScores map[Vertex]float64) {
+	pass := g.brandesPass(source)
+	delta := make(map[Vertex]float64, len(pass.order))
+	for i := len(pass.order) - 1; i >= 0; i-- {
+		w := pass.order[i]
+		//The successors of w come later in the order,
+		//so its dependency is complete by now
+		if vertexScores != nil && w != source {
+			vertexScores[w] += delta[w]
+		}
+		for _, ref := range pass.preds[w] {
+			credit := pass.sigma[ref.from] / pass.sigma[w] * (1 + delta[w])
+			if edgeScores != nil {
+				edgeScores[ref] += credit
+			}
+			delta[ref.from] += credit
+		}
+	}
+}
+
+// VertexBetweenness computes the betweenness centrality of
+// every vertex: for each ordered pair of distinct vertices
+// (s, t), every other vertex is credited with the fraction of
+// the shortest paths from s to t that pass through it, so
+// that the vertices carrying the most traffic score highest.
+// Equal-cost paths share the credit proportionally, and the
+// endpoints of a path get no credit for it. Disabled edges
+// are never traversed, like EdgeBetweenness does.
+// Scores are accumulated as float64 values, with the same
+// accuracy as EdgeBetweenness. The result has an entry for
+// every vertex of the graph.
+func (g *Graph) VertexBetweenness() map[Vertex]float64 {
+	if g == nil {
+		return nil
+	}
+	scores := make(map[Vertex]float64, len(g.VertexSet))
+	for v := range g.VertexSet {
+		scores[v] = 0
+	}
+	for source := range g.VertexSet {
+		g.accumulateBetweenness(source, nil, scores)
+	}
+	return scores
+}
+
+// ClosenessCentrality computes how close every vertex is to
+// the others, as the inverse of the average shortest distance
+// from the vertex to the vertices it can reach. Vertices that
+// cannot be reached do not contribute to the distance; instead,
+// following the Wasserman and Faust convention, the score is
+// scaled by the fraction of the other vertices that can be
+// reached, so that a vertex reaching few others close by does
+// not outrank one reaching all of them:
+//
+//	closeness(v) = (r / (n-1)) * (r / sum of distances)
+//
+// where n is the number of vertices and r the number of other
+// vertices v reaches. Vertices that reach no other vertex, or
+// reach them all at zero cost, score 0 and +Inf respectively.
+func (g *Graph) ClosenessCentrality() map[Vertex]float64 {
+	if g == nil {
+		return nil
+	}
+	n := len(g.VertexSet)
+	closeness := make(map[Vertex]float64, n)
+	for v := range g.VertexSet {
+		distSet, _ := g.distances(v, newQuery(nil))
+		reached := float64(len(distSet) - 1)
+		if reached == 0 {
+			closeness[v] = 0
+			continue
+		}
+		total := 0.0
+		for _, dist := range distSet {
+			total += float64(dist)
+		}
+		closeness[v] = reached / float64(n-1) * reached / total
+	}
+	return closeness
+}
+
+// MostCentralVertex returns the vertex with the highest
+// closeness centrality, i.e. the one with the smallest total
+// shortest distance to all the others when they are all
+// reachable. Ties are broken by vertex ID.
+// An empty graph has no central vertex and makes
+// MostCentralVertex fail with ErrEmptyGraph.
+func (g *Graph) MostCentralVertex() (Vertex, error) {
+	if g == nil {
+		return Vertex{}, ErrNilGraph
+	}
+	if len(g.VertexSet) == 0 {
+		return Vertex{}, ErrEmptyGraph
+	}
+	closeness := g.ClosenessCentrality()
+	vertices := g.Vertices()
+	central := vertices[0]
+	for _, v := range vertices[1:] {
+		if closeness[v] > closeness[central] {
+			central = v
+		}
+	}
+	return central, nil
+}
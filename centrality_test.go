@@ -0,0 +1,247 @@
+package cspf_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// generateDiamondChain builds n diamonds in a row, so that
+// the number of shortest paths from the first to the last
+// vertex is 2^n.
+func generateDiamondChain(n int) *cspf.Graph {
+	graph := cspf.Graph{}
+	joint := func(i int) cspf.Vertex { return cspf.Vertex{ID: fmt.Sprintf("j%03d", i)} }
+	for i := 0; i < n; i++ {
+		up := cspf.Vertex{ID: fmt.Sprintf("u%03d", i)}
+		down := cspf.Vertex{ID: fmt.Sprintf("d%03d", i)}
+		graph.AddEdge(joint(i), up, 1)
+		graph.AddEdge(joint(i), down, 1)
+		graph.AddEdge(up, joint(i+1), 1)
+		graph.AddEdge(down, joint(i+1), 1)
+	}
+	return &graph
+}
+
+// sumOfHopDistances returns the sum of the hop distances of
+// all the ordered pairs of distinct, connected vertices.
+func sumOfHopDistances(graph *cspf.Graph) float64 {
+	total := 0.0
+	for source := range graph.VertexSet {
+		hops := map[cspf.Vertex]int{source: 0}
+		frontier := []cspf.Vertex{source}
+		for len(frontier) > 0 {
+			v := frontier[0]
+			frontier = frontier[1:]
+			for _, edge := range graph.VertexSet[v] {
+				if _, ok := hops[edge.To]; !ok {
+					hops[edge.To] = hops[v] + 1
+					total += float64(hops[edge.To])
+					frontier = append(frontier, edge.To)
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestEdgeBetweenness(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("Equal-cost paths share the credit", t, func() {
+		scores := graph.EdgeBetweenness()
+		So(len(scores), ShouldEqual, 4)
+		//Each edge of the diamond carries the direct pair (1)
+		//plus half of the a -> d pair (0.5)
+		for _, score := range scores {
+			So(score.Score, ShouldAlmostEqual, 1.5)
+		}
+		So(scores[0].Edge.From, ShouldResemble, a)
+		So(scores[0].Edge.To, ShouldResemble, b)
+		So(scores[3].Edge.From, ShouldResemble, c)
+	})
+
+	Convey("Disabled edges carry no traffic", t, func() {
+		scores := generateDisabledDiamond().EdgeBetweenness()
+		So(len(scores), ShouldEqual, 4)
+		//a->b, a->c, b->d, c->d
+		So(scores[0].Score, ShouldAlmostEqual, 0)
+		So(scores[1].Score, ShouldAlmostEqual, 2)
+		So(scores[2].Score, ShouldAlmostEqual, 1)
+		So(scores[3].Score, ShouldAlmostEqual, 2)
+	})
+
+	Convey("Call EdgeBetweenness on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.EdgeBetweenness(), ShouldBeNil)
+	})
+}
+
+func TestEdgeBetweennessLargeGraph(t *testing.T) {
+	//2^100 shortest paths connect the two ends of the chain,
+	//far beyond what a uint64 counter can hold
+	graph := generateDiamondChain(100)
+
+	Convey("Sum of edge betweenness equals the sum of hop distances", t, func() {
+		scores := graph.EdgeBetweenness()
+		So(len(scores), ShouldEqual, 400)
+		sum := 0.0
+		for _, score := range scores {
+			So(math.IsNaN(score.Score), ShouldBeFalse)
+			So(math.IsInf(score.Score, 0), ShouldBeFalse)
+			sum += score.Score
+		}
+		expected := sumOfHopDistances(graph)
+		So(math.Abs(sum-expected)/expected, ShouldBeLessThan, 1e-9)
+	})
+}
+
+func TestVertexBetweenness(t *testing.T) {
+	a1 := cspf.Vertex{ID: "a1"}
+	a2 := cspf.Vertex{ID: "a2"}
+	a3 := cspf.Vertex{ID: "a3"}
+	b1 := cspf.Vertex{ID: "b1"}
+	b2 := cspf.Vertex{ID: "b2"}
+	b3 := cspf.Vertex{ID: "b3"}
+	m := cspf.Vertex{ID: "m"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate two triangles joined through a bottleneck", t, func() {
+		for _, triangle := range [][]cspf.Vertex{{a1, a2, a3}, {b1, b2, b3}} {
+			So(graph.AddMixedEdge(triangle[0], triangle[1], 1, true), ShouldBeNil)
+			So(graph.AddMixedEdge(triangle[1], triangle[2], 1, true), ShouldBeNil)
+			So(graph.AddMixedEdge(triangle[2], triangle[0], 1, true), ShouldBeNil)
+		}
+		So(graph.AddMixedEdge(a1, m, 1, true), ShouldBeNil)
+		So(graph.AddMixedEdge(m, b1, 1, true), ShouldBeNil)
+	})
+
+	Convey("The bottleneck carries the most traffic", t, func() {
+		scores := graph.VertexBetweenness()
+		So(len(scores), ShouldEqual, 7)
+		//m is on the paths of the 9 pairs across, both ways
+		So(scores[m], ShouldAlmostEqual, 18)
+		//a1 is on the paths from a2 and a3 to m and the b's
+		So(scores[a1], ShouldAlmostEqual, 16)
+		So(scores[b1], ShouldAlmostEqual, 16)
+		So(scores[a2], ShouldEqual, 0)
+		for v, score := range scores {
+			if v != m {
+				So(score, ShouldBeLessThan, scores[m])
+			}
+		}
+	})
+
+	Convey("Equal-cost paths share the credit", t, func() {
+		a := cspf.Vertex{ID: "a"}
+		b := cspf.Vertex{ID: "b"}
+		c := cspf.Vertex{ID: "c"}
+		d := cspf.Vertex{ID: "d"}
+		diamond := cspf.Graph{}
+		So(diamond.AddEdge(a, b, 1), ShouldBeNil)
+		So(diamond.AddEdge(a, c, 1), ShouldBeNil)
+		So(diamond.AddEdge(b, d, 1), ShouldBeNil)
+		So(diamond.AddEdge(c, d, 1), ShouldBeNil)
+		So(diamond.VertexBetweenness(), ShouldResemble, map[cspf.Vertex]float64{a: 0, b: 0.5, c: 0.5, d: 0})
+	})
+
+	Convey("Disabled edges carry no traffic", t, func() {
+		scores := generateDisabledDiamond().VertexBetweenness()
+		So(scores[cspf.Vertex{ID: "b"}], ShouldAlmostEqual, 0)
+		So(scores[cspf.Vertex{ID: "c"}], ShouldAlmostEqual, 1)
+	})
+
+	Convey("Paths whose cost overflows are unreachable", t, func() {
+		x := cspf.Vertex{ID: "x"}
+		y := cspf.Vertex{ID: "y"}
+		z := cspf.Vertex{ID: "z"}
+		overflowing := cspf.Graph{}
+		So(overflowing.AddEdge(x, y, math.MaxUint64/2+1), ShouldBeNil)
+		So(overflowing.AddEdge(y, z, math.MaxUint64/2+1), ShouldBeNil)
+		So(overflowing.VertexBetweenness()[y], ShouldAlmostEqual, 0)
+		So(overflowing.AddEdge(x, z, 5), ShouldBeNil)
+		So(overflowing.VertexBetweenness()[y], ShouldAlmostEqual, 0)
+	})
+
+	Convey("Call VertexBetweenness on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.VertexBetweenness(), ShouldBeNil)
+	})
+}
+
+func TestClosenessCentrality(t *testing.T) {
+	center := cspf.Vertex{ID: "center"}
+	leaves := []cspf.Vertex{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a star graph", t, func() {
+		for _, leaf := range leaves {
+			So(graph.AddMixedEdge(center, leaf, 1, true), ShouldBeNil)
+		}
+	})
+
+	Convey("The center is the closest to all the others", t, func() {
+		closeness := graph.ClosenessCentrality()
+		So(len(closeness), ShouldEqual, 5)
+		So(closeness[center], ShouldAlmostEqual, 1)
+		//A leaf is 1 hop from the center and 2 from the other leaves
+		for _, leaf := range leaves {
+			So(closeness[leaf], ShouldAlmostEqual, 4.0/7.0)
+		}
+		central, err := graph.MostCentralVertex()
+		So(err, ShouldBeNil)
+		So(central, ShouldResemble, center)
+	})
+
+	Convey("Unreachable vertices scale the score down", t, func() {
+		directed := cspf.Graph{}
+		So(directed.AddEdge(leaves[0], leaves[1], 1), ShouldBeNil)
+		So(directed.AddEdge(leaves[2], leaves[0], 1), ShouldBeNil)
+		So(directed.AddEdge(leaves[2], leaves[3], 1), ShouldBeNil)
+		closeness := directed.ClosenessCentrality()
+		//c reaches a, d at 1 and b at 2
+		So(closeness[leaves[2]], ShouldAlmostEqual, 3.0/3.0*3.0/4.0)
+		//a only reaches b, at 1
+		So(closeness[leaves[0]], ShouldAlmostEqual, 1.0/3.0)
+		So(closeness[leaves[1]], ShouldEqual, 0)
+		central, err := directed.MostCentralVertex()
+		So(err, ShouldBeNil)
+		So(central, ShouldResemble, leaves[2])
+	})
+
+	Convey("Ties are broken by ID", t, func() {
+		cycle := cspf.Graph{}
+		So(cycle.AddEdge(leaves[1], leaves[0], 1), ShouldBeNil)
+		So(cycle.AddEdge(leaves[0], leaves[1], 1), ShouldBeNil)
+		central, err := cycle.MostCentralVertex()
+		So(err, ShouldBeNil)
+		So(central, ShouldResemble, leaves[0])
+	})
+
+	Convey("Empty and nil graphs have no central vertex", t, func() {
+		_, err := (&cspf.Graph{}).MostCentralVertex()
+		So(err, ShouldBeError, cspf.ErrEmptyGraph)
+		var nilGraph *cspf.Graph
+		So(nilGraph.ClosenessCentrality(), ShouldBeNil)
+		_, err = nilGraph.MostCentralVertex()
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
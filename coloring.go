@@ -0,0 +1,62 @@
+package cspf
+
+import "sort"
+
+// GreedyColoring assigns a color index to every vertex of the
+// graph so that the two ends of every edge have different
+// colors, as in a channel assignment where edges are conflicts.
+// Edges are treated as undirected and self-loops are ignored.
+// Vertices are colored in order of decreasing degree, ties
+// broken by ID, each taking the smallest color index that none
+// of its colored neighbors has, so the indices used are
+// consecutive from 0: ColorCount reports how many colors the
+// coloring uses. The coloring is proper but not guaranteed to
+// use the fewest colors possible.
+func (g *Graph) GreedyColoring() map[Vertex]int {
+	if g == nil {
+		return nil
+	}
+	neighbors := make(map[Vertex]map[Vertex]bool, len(g.VertexSet))
+	for v := range g.VertexSet {
+		neighbors[v] = make(map[Vertex]bool)
+	}
+	for v, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.To == v {
+				continue
+			}
+			neighbors[v][edge.To] = true
+			neighbors[edge.To][v] = true
+		}
+	}
+
+	vertices := g.Vertices()
+	sort.SliceStable(vertices, func(i, j int) bool {
+		return len(neighbors[vertices[i]]) > len(neighbors[vertices[j]])
+	})
+	colors := make(map[Vertex]int, len(vertices))
+	for _, v := range vertices {
+		used := make(map[int]bool)
+		for n := range neighbors[v] {
+			if color, ok := colors[n]; ok {
+				used[color] = true
+			}
+		}
+		color := 0
+		for used[color] {
+			color++
+		}
+		colors[v] = color
+	}
+	return colors
+}
+
+// ColorCount returns the number of distinct colors of a coloring,
+// such as the one returned by GreedyColoring.
+func ColorCount(colors map[Vertex]int) int {
+	distinct := make(map[int]bool)
+	for _, color := range colors {
+		distinct[color] = true
+	}
+	return len(distinct)
+}
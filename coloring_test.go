@@ -0,0 +1,69 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// properColoring reports whether the ends of every edge,
+// other than self-loops, have different colors.
+func properColoring(graph *cspf.Graph, colors map[cspf.Vertex]int) bool {
+	for v, edges := range graph.VertexSet {
+		for _, edge := range edges {
+			if edge.To != v && colors[v] == colors[edge.To] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestGreedyColoring(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	Convey("A triangle needs three colors", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1), ShouldBeNil)
+		colors := graph.GreedyColoring()
+		So(len(colors), ShouldEqual, 3)
+		So(properColoring(&graph, colors), ShouldBeTrue)
+		So(cspf.ColorCount(colors), ShouldEqual, 3)
+	})
+
+	Convey("A path needs two colors", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, d, 1), ShouldBeNil)
+		colors := graph.GreedyColoring()
+		So(len(colors), ShouldEqual, 4)
+		So(properColoring(&graph, colors), ShouldBeTrue)
+		So(cspf.ColorCount(colors), ShouldEqual, 2)
+		So(colors[b], ShouldEqual, 0)
+		So(colors[a], ShouldEqual, 1)
+	})
+
+	Convey("Isolated vertices share the first color", t, func() {
+		graph := cspf.Graph{}
+		graph.AddNode(a)
+		graph.AddNode(b)
+		colors := graph.GreedyColoring()
+		So(colors, ShouldResemble, map[cspf.Vertex]int{a: 0, b: 0})
+		So(cspf.ColorCount(colors), ShouldEqual, 1)
+	})
+
+	Convey("Call GreedyColoring on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.GreedyColoring(), ShouldBeNil)
+		So(cspf.ColorCount(nil), ShouldEqual, 0)
+	})
+}
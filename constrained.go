@@ -0,0 +1,369 @@
+package cspf
+
+// CSPFResult is the detailed outcome of a CSPF query.
+type CSPFResult struct {
+	// Graph is the result graph, as returned by CSPF.
+	Graph *Graph
+	// MatchedEdges lists the enabled edges of the
+	// queried graph that satisfy the expression.
+	MatchedEdges []Edge
+	// PrunedEdges lists the edges of the queried graph
+	// that CSPF cannot traverse: the ones that do not
+	// satisfy the expression, and the disabled ones
+	// even if they do.
+	PrunedEdges []Edge
+	// Expr is the expression of the query.
+	Expr string
+}
+
+// CSPFDetailed runs the CSPF algorithm like CSPF does, but
+// it returns a CSPFResult that also reports which edges
+// of the graph were matched or pruned by the expression.
+// Matched and pruned edges are listed by source vertex ID
+// and then in insertion order.
+func (g *Graph) CSPFDetailed(from, to Vertex, exp string, opts ...Option) (*CSPFResult, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CSPFResult{
+		MatchedEdges: []Edge{},
+		PrunedEdges:  []Edge{},
+		Expr:         exp,
+	}
+	result.Graph, err = g.spf(from, to, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range g.Vertices() {
+		for i, edge := range g.VertexSet[v] {
+			satisfied, err := q.edgeSatisfiesConstranints(edgeRef{from: v, index: i}, edge)
+			if err != nil {
+				return nil, err
+			}
+			if satisfied {
+				result.MatchedEdges = append(result.MatchedEdges, edge)
+			} else {
+				result.PrunedEdges = append(result.PrunedEdges, edge)
+			}
+		}
+	}
+	return result, nil
+}
+
+// AllPairsConstrained computes the shortest distance between
+// every pair of vertices of the graph, only considering the edges
+// that satisfy the specified expression.
+// The expression is compiled once and a constrained Dijkstra
+// algorithm is run from every vertex. Each edge is evaluated
+// against the expression only once across all the runs.
+// Pairs of vertices that are not connected under the
+// constraint are at Unreachable distance.
+func (g *Graph) AllPairsConstrained(exp string, opts ...Option) (map[Vertex]map[Vertex]uint64, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[Vertex]map[Vertex]uint64, len(g.VertexSet))
+	for source := range g.VertexSet {
+		distSet, _, err := g.shortestPaths(source, q)
+		if err != nil {
+			return nil, err
+		}
+		for v := range g.VertexSet {
+			if _, ok := distSet[v]; !ok {
+				distSet[v] = Unreachable
+			}
+		}
+		result[source] = distSet
+	}
+	return result, nil
+}
+
+// CSPFMinMatches builds a result graph containing the cheapest
+// simple path from one vertex to another that traverses at least
+// minMatches edges satisfying the expression. Unlike CSPF, edges
+// that do not satisfy the expression can still be traversed, they
+// just do not count towards the minimum.
+// The search runs on (vertex, matches so far) states, which finds
+// the cheapest walk collecting the required matches. When that
+// walk visits a vertex more than once, the simple paths are
+// enumerated in ascending cost order, as PathIterator does, until
+// one has enough matches, which can take exponential time.
+// The result graph is empty if no such path exists.
+func (g *Graph) CSPFMinMatches(from, to Vertex, exp string, minMatches int, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+	if minMatches < 0 {
+		minMatches = 0
+	}
+	path, err := g.stateSearch(from, to, q, func(matches int, ref edgeRef, edge Edge) (int, bool, error) {
+		match, err := q.edgeSatisfiesConstranints(ref, edge)
+		if err != nil {
+			return 0, false, err
+		}
+		if match && matches < minMatches {
+			matches++
+		}
+		return matches, true, nil
+	}, func(matches int) bool {
+		return matches == minMatches
+	})
+	if err != nil {
+		return nil, err
+	}
+	if path != nil && !IsSimplePath(path) {
+		//No simple path is cheaper than the cheapest walk,
+		//but the cheapest simple path can cost more
+		path, err = g.minMatchesSimplePath(from, to, q, minMatches)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := Graph{}
+	for _, edge := range path {
+		result.addEdge(edge)
+	}
+	return &result, nil
+}
+
+// minMatchesSimplePath returns the first simple path, in
+// ascending cost order, that traverses at least minMatches
+// edges satisfying the query, or nil if there is none.
+func (g *Graph) minMatchesSimplePath(from, to Vertex, q *query, minMatches int) ([]Edge, error) {
+	it := &PathIter{graph: g, from: from, to: to}
+	for {
+		path, ok := it.Next()
+		if !ok {
+			return nil, nil
+		}
+		matches := 0
+		for _, edge := range path {
+			//Parallel edges that are equal match alike,
+			//so the first one stands for all of them
+			for i, other := range g.VertexSet[edge.From] {
+				if !other.equal(edge) {
+					continue
+				}
+				match, err := q.edgeSatisfiesConstranints(edgeRef{from: edge.From, index: i}, other)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					matches++
+				}
+				break
+			}
+		}
+		if matches >= minMatches {
+			return path, nil
+		}
+	}
+}
+
+// searchState is a vertex reached by a walk together
+// with a counter of the walk, such as its number of hops.
+type searchState struct {
+	vertex  Vertex
+	counter int
+}
+
+// stateSearch runs the Dijkstra algorithm on (vertex, counter)
+// states and returns the cheapest walk from one vertex to a
+// state of the other for which done is true, or nil if there is
+// none. The walk starts with a zero counter, and next returns
+// the counter of the walk extended with an enabled edge, or
+// false if the edge cannot extend it.
+// The counters must be bounded for the search to terminate.
+func (g *Graph) stateSearch(from, to Vertex, q *query, next func(counter int, ref edgeRef, edge Edge) (int, bool, error), done func(counter int) bool) ([]Edge, error) {
+	start := searchState{vertex: from}
+	distSet := map[searchState]uint64{start: 0}
+	prevSet := make(map[searchState]Edge)
+	prevState := make(map[searchState]searchState)
+	visited := make(map[searchState]bool)
+	queue := vertexQueue{}
+	queue.pushState(start, 0)
+	for queue.Len() > 0 {
+		item := queue.pop()
+		closest := searchState{vertex: item.vertex, counter: item.counter}
+		if visited[closest] || item.dist > distSet[closest] {
+			continue
+		}
+		if closest.vertex == to && done(closest.counter) {
+			walk := []Edge{}
+			for s := closest; s != start; s = prevState[s] {
+				walk = append(walk, prevSet[s])
+			}
+			for i, j := 0, len(walk)-1; i < j; i, j = i+1, j-1 {
+				walk[i], walk[j] = walk[j], walk[i]
+			}
+			return walk, nil
+		}
+		visited[closest] = true
+
+		for i, edge := range g.VertexSet[closest.vertex] {
+			if edge.Disabled {
+				continue
+			}
+			counter, ok, err := next(closest.counter, edgeRef{from: closest.vertex, index: i}, edge)
+			if err != nil {
+				return nil, err
+			}
+			state := searchState{vertex: edge.To, counter: counter}
+			if !ok || visited[state] {
+				continue
+			}
+			dist := addCost(item.dist, q.cost(edge))
+			if current, ok := distSet[state]; !ok || dist < current {
+				distSet[state] = dist
+				prevSet[state] = edge
+				prevState[state] = closest
+				queue.pushState(state, dist)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ConstrainedPaths lists all the simple paths that connect
+// from one vertex to the other only traversing enabled edges
+// that satisfy the expression. Unlike CSPF, every such path
+// is listed, not only the shortest ones.
+// Paths are listed in Depth-First Search order, like Paths
+// does, and each edge is evaluated at most once.
+func (g *Graph) ConstrainedPaths(from, to Vertex, exp string, opts ...Option) ([][]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+	paths := [][]Edge{}
+	err = g.walkPaths(from, to, func(ref edgeRef, edge Edge, _ int, _ uint64) (bool, error) {
+		return q.edgeSatisfiesConstranints(ref, edge)
+	}, func(path []Edge) {
+		paths = append(paths, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// AssertPathSatisfies evaluates the expression on every edge
+// of the path, as given, and reports whether all of them
+// satisfy it, listing the ones that do not in path order.
+// It validates paths chosen outside of the package, e.g. to
+// check that no edge of a path is red with `link != "red"`.
+// Unlike the queries, it does not consider the state of the
+// edges, nor whether the path is part of the graph.
+func (g *Graph) AssertPathSatisfies(path []Edge, exp string, opts ...Option) (bool, []Edge, error) {
+	if g == nil {
+		return false, nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return false, nil, err
+	}
+	violators := []Edge{}
+	for _, edge := range path {
+		match, err := q.evalBool(q.parameters(edge))
+		if err != nil {
+			return false, nil, err
+		}
+		if !match {
+			violators = append(violators, edge)
+		}
+	}
+	return len(violators) == 0, violators, nil
+}
+
+// ConstrainedReachableWithinHops returns every vertex that can be
+// reached from the given one in at most maxHops edges, only
+// traversing enabled edges that satisfy the expression, along
+// with the smallest number of hops needed to reach it.
+// The source itself is reached in 0 hops, if it is part of the
+// graph. A negative hop limit is treated as zero.
+func (g *Graph) ConstrainedReachableWithinHops(from Vertex, exp string, maxHops int, opts ...Option) (map[Vertex]int, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+	hops := make(map[Vertex]int)
+	if _, ok := g.VertexSet[from]; !ok {
+		return hops, nil
+	}
+	hops[from] = 0
+	frontier := []Vertex{from}
+	for hop := 1; hop <= maxHops && len(frontier) > 0; hop++ {
+		var next []Vertex
+		for _, v := range frontier {
+			for i, edge := range g.VertexSet[v] {
+				if _, ok := hops[edge.To]; ok {
+					continue
+				}
+				satisfied, err := q.edgeSatisfiesConstranints(edgeRef{from: v, index: i}, edge)
+				if err != nil {
+					return nil, err
+				}
+				if satisfied {
+					hops[edge.To] = hop
+					next = append(next, edge.To)
+				}
+			}
+		}
+		frontier = next
+	}
+	return hops, nil
+}
+
+// ConstrainedSubgraph returns a new graph with all the enabled
+// edges of the graph that satisfy the expression, together with
+// their vertices, e.g. to visualize the coverage of a policy
+// before routing on it. Vertices with no such edge are left out.
+// The edges keep their IDs and a copy of their tags.
+func (g *Graph) ConstrainedSubgraph(exp string, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	err := g.compile(q, exp)
+	if err != nil {
+		return nil, err
+	}
+	subgraph := Graph{}
+	for _, v := range g.Vertices() {
+		for i, edge := range g.VertexSet[v] {
+			satisfied, err := q.edgeSatisfiesConstranints(edgeRef{from: v, index: i}, edge)
+			if err != nil {
+				return nil, err
+			}
+			if satisfied {
+				subgraph.addEdge(edge.withTagsCopy())
+			}
+		}
+	}
+	return &subgraph, nil
+}
@@ -0,0 +1,495 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSPFDetailed(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagRed), ShouldBeNil)
+	})
+
+	Convey("Run the detailed CSPF algorithm", t, func() {
+		exp := `link == "blue"`
+		result, err := graph.CSPFDetailed(a, d, exp)
+		So(err, ShouldBeNil)
+		So(result.Expr, ShouldEqual, exp)
+
+		paths := result.Graph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d")
+
+		So(len(result.MatchedEdges), ShouldEqual, 2)
+		So(cspf.Path{result.MatchedEdges[0]}.String(), ShouldEqual, "a->b")
+		So(cspf.Path{result.MatchedEdges[1]}.String(), ShouldEqual, "b->d")
+		So(len(result.PrunedEdges), ShouldEqual, 2)
+		So(cspf.Path{result.PrunedEdges[0]}.String(), ShouldEqual, "a->c")
+		So(cspf.Path{result.PrunedEdges[1]}.String(), ShouldEqual, "c->d")
+	})
+
+	Convey("Disabled edges are pruned even if they match", t, func() {
+		disabled := cspf.Graph{}
+		So(disabled.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(disabled.SetEdgeEnabled(a, b, false), ShouldEqual, 1)
+		result, err := disabled.CSPFDetailed(a, b, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(result.MatchedEdges, ShouldBeEmpty)
+		So(result.PrunedEdges, ShouldResemble, disabled.VertexSet[a])
+	})
+
+	Convey("Run the detailed CSPF algorithm with an invalid expression", t, func() {
+		result, err := graph.CSPFDetailed(a, d, `link == "blue" or link == "red"`)
+		So(err, ShouldNotBeNil)
+		So(result, ShouldBeNil)
+	})
+
+	Convey("Call CSPFDetailed on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		result, err := nilGraph.CSPFDetailed(a, d, `true`)
+		So(result, ShouldBeNil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestAllPairsConstrained(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 3, tagBlue), ShouldBeNil)
+	})
+
+	Convey("Compute the constrained distances between all pairs", t, func() {
+		exp := `link == "blue"`
+		dist, err := graph.AllPairsConstrained(exp)
+		So(err, ShouldBeNil)
+		So(len(dist), ShouldEqual, 4)
+		So(dist[a][a], ShouldEqual, 0)
+		So(dist[a][c], ShouldEqual, 2)
+		So(dist[a][b], ShouldEqual, cspf.Unreachable)
+		So(dist[d][a], ShouldEqual, cspf.Unreachable)
+
+		//Compare with the result of a single CSPF query
+		cspfGraph, err := graph.CSPF(a, d, exp)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.PathList(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(dist[a][d], ShouldEqual, paths[0].Cost())
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		dist, err := graph.AllPairsConstrained(`link == "blue" or link == "red"`)
+		So(err, ShouldNotBeNil)
+		So(dist, ShouldBeNil)
+	})
+
+	Convey("Call AllPairsConstrained on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.AllPairsConstrained(`true`)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestConstraintEvaluationCache(t *testing.T) {
+	graph, _ := generateFullyConnectedGraph(20, true)
+	evaluations := 0
+	counted := cspf.WithFunction("counted", func(value interface{}) interface{} {
+		evaluations++
+		return value
+	})
+
+	Convey("Every edge is evaluated once per query", t, func() {
+		_, err := graph.AllPairsConstrained(`counted(key) == "value"`, counted)
+		So(err, ShouldBeNil)
+		//Self-loops are never evaluated, since they
+		//always lead back to a visited vertex
+		So(evaluations, ShouldEqual, 20*19)
+	})
+
+	Convey("The cache does not leak across queries", t, func() {
+		evaluations = 0
+		_, err := graph.AllPairsConstrained(`counted(key) == "value"`, counted)
+		So(err, ShouldBeNil)
+		So(evaluations, ShouldEqual, 20*19)
+	})
+
+	Convey("Without the cache, every run evaluates the edges again", t, func() {
+		//One CSPF query per source is what AllPairsConstrained
+		//would cost if the runs did not share their evaluations
+		evaluations = 0
+		vertices := graph.Vertices()
+		for _, source := range vertices {
+			_, err := graph.CSPF(source, vertices[0], `counted(key) == "value"`, counted)
+			So(err, ShouldBeNil)
+		}
+		So(evaluations, ShouldBeGreaterThan, 20*19)
+	})
+}
+
+func BenchmarkAllPairsConstrained(b *testing.B) {
+	graph, _ := generateFullyConnectedGraph(30, true)
+	vertices := graph.Vertices()
+	evaluations := 0
+	counted := cspf.WithFunction("counted", func(value interface{}) interface{} {
+		evaluations++
+		return value
+	})
+	b.Run("cached", func(b *testing.B) {
+		evaluations = 0
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := graph.AllPairsConstrained(`counted(key) == "value"`, counted)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(evaluations)/float64(b.N), "evals/op")
+	})
+	//The baseline runs one CSPF query per source,
+	//so no evaluation is shared across the runs
+	b.Run("uncached", func(b *testing.B) {
+		evaluations = 0
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, source := range vertices {
+				_, err := graph.CSPF(source, vertices[0], `counted(key) == "value"`, counted)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(evaluations)/float64(b.N), "evals/op")
+	})
+}
+
+func TestCSPFMinMatches(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	encrypted := cspf.Tag{Key: "encrypted", Value: true}
+	plain := cspf.Tag{Key: "encrypted", Value: false}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		//A -> B -> E is the shortest, with one encrypted hop
+		So(graph.AddEdge(a, b, 1, encrypted), ShouldBeNil)
+		So(graph.AddEdge(b, e, 1, plain), ShouldBeNil)
+		//A -> C -> D -> E is longer, with two encrypted hops
+		So(graph.AddEdge(a, c, 2, encrypted), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, plain), ShouldBeNil)
+		So(graph.AddEdge(d, e, 2, encrypted), ShouldBeNil)
+	})
+
+	Convey("One match is met by the shortest path", t, func() {
+		result, err := graph.CSPFMinMatches(a, e, `encrypted`, 1)
+		So(err, ShouldBeNil)
+		paths := result.PathList(a, e)
+		So(len(paths), ShouldEqual, 1)
+		So(paths[0].Cost(), ShouldEqual, 2)
+	})
+
+	Convey("Two matches require the longer path", t, func() {
+		result, err := graph.CSPFMinMatches(a, e, `encrypted`, 2)
+		So(err, ShouldBeNil)
+		paths := result.PathList(a, e)
+		So(len(paths), ShouldEqual, 1)
+		So(paths[0].Cost(), ShouldEqual, 6)
+		So(paths[0][1].To, ShouldResemble, d)
+	})
+
+	Convey("No path has three matches", t, func() {
+		result, err := graph.CSPFMinMatches(a, e, `encrypted`, 3)
+		So(err, ShouldBeNil)
+		So(result.VertexSet, ShouldBeEmpty)
+	})
+
+	Convey("Only simple paths are returned", t, func() {
+		//A -> B -> A -> B -> C is the cheapest way to collect
+		//two matches, but it revisits A and B
+		loop := cspf.Graph{}
+		So(loop.AddEdge(a, b, 1, encrypted), ShouldBeNil)
+		So(loop.AddEdge(b, a, 1, plain), ShouldBeNil)
+		So(loop.AddEdge(b, c, 1, plain), ShouldBeNil)
+		result, err := loop.CSPFMinMatches(a, c, `encrypted`, 2)
+		So(err, ShouldBeNil)
+		So(result.VertexSet, ShouldBeEmpty)
+
+		//A -> D -> C is the cheapest simple path with two matches
+		So(loop.AddEdge(a, d, 10, encrypted), ShouldBeNil)
+		So(loop.AddEdge(d, c, 10, encrypted), ShouldBeNil)
+		result, err = loop.CSPFMinMatches(a, c, `encrypted`, 2)
+		So(err, ShouldBeNil)
+		So(result.PathStrings(a, c), ShouldResemble, []string{"A->D->C"})
+		So(result.VertexSet[b], ShouldBeEmpty)
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		_, err := graph.CSPFMinMatches(a, e, `encrypted ==`, 1)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Call CSPFMinMatches on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.CSPFMinMatches(a, e, `encrypted`, 1)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestConstrainedPaths(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+	tagRed := cspf.Tag{Key: "link", Value: "red"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, c, 5, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagBlue), ShouldBeNil)
+	})
+
+	Convey("List all the blue-only paths", t, func() {
+		paths, err := graph.ConstrainedPaths(a, d, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 2)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "A->B->C->D")
+		So(cspf.Path(paths[1]).String(), ShouldEqual, "A->C->D")
+	})
+
+	Convey("Unconstrained paths include the red ones", t, func() {
+		paths, err := graph.ConstrainedPaths(a, d, `true`)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, len(graph.Paths(a, d)))
+		So(len(paths), ShouldEqual, 4)
+	})
+
+	Convey("No path satisfies the expression", t, func() {
+		paths, err := graph.ConstrainedPaths(a, d, `link == "green"`)
+		So(err, ShouldBeNil)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		paths, err := graph.ConstrainedPaths(a, d, `link == "blue" or`)
+		So(err, ShouldNotBeNil)
+		So(paths, ShouldBeNil)
+	})
+
+	Convey("Call ConstrainedPaths on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.ConstrainedPaths(a, d, `true`)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestAssertPathSatisfies(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+	tagRed := cspf.Tag{Key: "link", Value: "red"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(a, d, 5, tagBlue), ShouldBeNil)
+	})
+
+	Convey("A mixed path lists its red edges as violators", t, func() {
+		path := graph.Paths(a, d)[0]
+		So(len(path), ShouldEqual, 3)
+		ok, violators, err := graph.AssertPathSatisfies(path, `link != "red"`)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+		So(violators, ShouldResemble, []cspf.Edge{path[1], path[2]})
+	})
+
+	Convey("A blue path satisfies the expression", t, func() {
+		path := graph.Paths(a, d)[1]
+		ok, violators, err := graph.AssertPathSatisfies(path, `link != "red"`)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(violators, ShouldBeEmpty)
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		_, _, err := graph.AssertPathSatisfies(nil, `link !=`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Call AssertPathSatisfies on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, err := nilGraph.AssertPathSatisfies(nil, `true`)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestConstrainedReachableWithinHops(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+	tagRed := cspf.Tag{Key: "link", Value: "red"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		//A -> B -> C -> D is all blue,
+		//A -> E -> D is a red shortcut
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, e, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(e, d, 1, tagRed), ShouldBeNil)
+	})
+
+	Convey("The hop limit alone reaches D through the shortcut", t, func() {
+		hops, err := graph.ConstrainedReachableWithinHops(a, `true`, 2)
+		So(err, ShouldBeNil)
+		So(hops, ShouldResemble, map[cspf.Vertex]int{a: 0, b: 1, e: 1, c: 2, d: 2})
+	})
+
+	Convey("The constraint alone reaches D through the blue links", t, func() {
+		hops, err := graph.ConstrainedReachableWithinHops(a, `link == "blue"`, 10)
+		So(err, ShouldBeNil)
+		So(hops, ShouldResemble, map[cspf.Vertex]int{a: 0, b: 1, c: 2, d: 3})
+	})
+
+	Convey("Both together do not reach D", t, func() {
+		hops, err := graph.ConstrainedReachableWithinHops(a, `link == "blue"`, 2)
+		So(err, ShouldBeNil)
+		So(hops, ShouldResemble, map[cspf.Vertex]int{a: 0, b: 1, c: 2})
+	})
+
+	Convey("No hops only reach the source", t, func() {
+		hops, err := graph.ConstrainedReachableWithinHops(a, `true`, -1)
+		So(err, ShouldBeNil)
+		So(hops, ShouldResemble, map[cspf.Vertex]int{a: 0})
+		hops, err = graph.ConstrainedReachableWithinHops(cspf.Vertex{ID: "Z"}, `true`, 3)
+		So(err, ShouldBeNil)
+		So(hops, ShouldBeEmpty)
+	})
+
+	Convey("Run with an invalid expression", t, func() {
+		_, err := graph.ConstrainedReachableWithinHops(a, `link ==`, 2)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Call ConstrainedReachableWithinHops on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.ConstrainedReachableWithinHops(a, `true`, 2)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestConstrainedSubgraph(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(d, b, 5, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, e, 1, tagBlue), ShouldBeNil)
+		So(graph.SetEdgeEnabled(c, e, false), ShouldEqual, 1)
+	})
+
+	Convey("Keep all the blue edges, not only the shortest paths", t, func() {
+		subgraph, err := graph.ConstrainedSubgraph(`link == "blue"`)
+		So(err, ShouldBeNil)
+		So(subgraph.Vertices(), ShouldResemble, []cspf.Vertex{a, b, d})
+		So(subgraph.CanonicalEdges(), ShouldResemble, []cspf.Edge{
+			{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"link": "blue"}},
+			{From: b, To: d, Cost: 1, Tags: map[string]interface{}{"link": "blue"}},
+			{From: d, To: b, Cost: 5, Tags: map[string]interface{}{"link": "blue"}},
+		})
+	})
+
+	Convey("The subgraph does not share the tags of the graph", t, func() {
+		subgraph, err := graph.ConstrainedSubgraph(`link == "blue"`)
+		So(err, ShouldBeNil)
+		subgraph.VertexSet[a][0].Tags["link"] = "green"
+		So(graph.VertexSet[a][0].Tags["link"], ShouldEqual, "blue")
+	})
+
+	Convey("An invalid expression is reported as an error", t, func() {
+		_, err := graph.ConstrainedSubgraph(`link ==`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Call ConstrainedSubgraph on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.ConstrainedSubgraph(`link == "blue"`)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
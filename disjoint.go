@@ -0,0 +1,236 @@
+package cspf
+
+import (
+	"math"
+	"sort"
+)
+
+// flowArc is an arc of the residual network
+// built by EdgeDisjointPaths and MinCut.
+type flowArc struct {
+	to int
+	// Index of the reverse arc in the adjacency of to.
+	rev      int
+	capacity int
+	cost     int64
+	// Edge of the graph the arc comes from, for forward arcs.
+	edge    Edge
+	forward bool
+}
+
+// EdgeDisjointPaths returns up to k paths from one vertex to
+// another that share no edge, for multipath load balancing.
+// The paths are found through successive shortest augmenting
+// paths on a network where every edge has unit capacity, so
+// their total cost is the minimum among all the sets of as
+// many edge-disjoint paths.
+// Fewer than k paths are returned if the edge connectivity
+// between the two vertices is lower than k. Paths are sorted
+// by cost, and then by their String representation.
+// Disabled edges and self-loops are never used, and parallel
+// edges count as distinct edges.
+func (g *Graph) EdgeDisjointPaths(from, to Vertex, k int) ([][]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	paths := [][]Edge{}
+	_, okFrom := g.VertexSet[from]
+	_, okTo := g.VertexSet[to]
+	if !okFrom || !okTo || from == to || k <= 0 {
+		return paths, nil
+	}
+
+	index, network := g.flowNetwork()
+	source, sink := index[from], index[to]
+	flow := 0
+	for flow < k && augmentShortestPath(network, source, sink) {
+		flow++
+	}
+
+	//Decompose the flow into paths, following
+	//the saturated forward arcs from the source
+	for i := 0; i < flow; i++ {
+		var path []Edge
+		visitedAt := map[int]int{source: 0}
+		for v := source; v != sink; {
+			for j := range network[v] {
+				arc := &network[v][j]
+				if !arc.forward || arc.capacity != 0 {
+					continue
+				}
+				//Consume the flow of the arc
+				arc.capacity = 1
+				path = append(path, arc.edge)
+				v = arc.to
+				break
+			}
+			//Drop the cycles of zero-cost flow
+			if at, ok := visitedAt[v]; ok {
+				for _, edge := range path[at:] {
+					delete(visitedAt, index[edge.To])
+				}
+				path = path[:at]
+			}
+			visitedAt[v] = len(path)
+		}
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return lessPath(paths[i], paths[j])
+	})
+	return paths, nil
+}
+
+// MinCostDisjointPair returns the two edge-disjoint paths from
+// one vertex to another with the minimum combined cost, e.g. for
+// 1+1 protection, along with that cost. The cheaper path comes
+// first. Unlike taking the shortest path and then the shortest
+// one avoiding its edges, which can fail or pick an expensive
+// second path, the pair is optimal: it runs two rounds of
+// successive shortest augmenting paths, found with Bellman-Ford
+// on the residual network, as EdgeDisjointPaths does for two
+// paths, so the second round can reroute the first path. This
+// finds the same pair as Suurballe's algorithm, at the cost of
+// Bellman-Ford rather than Dijkstra for every round.
+// If there are no two edge-disjoint paths, both paths are empty
+// and the cost is Unreachable.
+func (g *Graph) MinCostDisjointPair(from, to Vertex) ([]Edge, []Edge, uint64, error) {
+	if g == nil {
+		return nil, nil, 0, ErrNilGraph
+	}
+	paths, err := g.EdgeDisjointPaths(from, to, 2)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if len(paths) < 2 {
+		return []Edge{}, []Edge{}, Unreachable, nil
+	}
+	return paths[0], paths[1], addCost(Path(paths[0]).Cost(), Path(paths[1]).Cost()), nil
+}
+
+// MinCut returns the minimum number of edges to remove to
+// disconnect one vertex from another, i.e. the edge connectivity
+// between the two, along with the edges of one such minimum cut,
+// sorted like CanonicalEdges. These are the weakest set of links
+// between the two vertices.
+// The cut is found through the maximum flow of a network where
+// every edge has unit capacity: it is made of the edges leaving
+// the vertices the source can still reach in the residual network.
+// Disabled edges and self-loops are never part of a cut, and
+// parallel edges count as distinct edges. The cut is empty if
+// the vertices are already disconnected or are the same vertex.
+func (g *Graph) MinCut(from, to Vertex) (value int, cutEdges []Edge, err error) {
+	if g == nil {
+		return 0, nil, ErrNilGraph
+	}
+	cutEdges = []Edge{}
+	_, okFrom := g.VertexSet[from]
+	_, okTo := g.VertexSet[to]
+	if !okFrom || !okTo || from == to {
+		return 0, cutEdges, nil
+	}
+
+	index, network := g.flowNetwork()
+	source, sink := index[from], index[to]
+	for augmentShortestPath(network, source, sink) {
+	}
+
+	//The vertices still reachable from the source
+	//are the source side of the minimum cut
+	reached := make([]bool, len(network))
+	reached[source] = true
+	pending := []int{source}
+	for len(pending) > 0 {
+		u := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, arc := range network[u] {
+			if arc.capacity > 0 && !reached[arc.to] {
+				reached[arc.to] = true
+				pending = append(pending, arc.to)
+			}
+		}
+	}
+	for u := range network {
+		if !reached[u] {
+			continue
+		}
+		for _, arc := range network[u] {
+			if arc.forward && !reached[arc.to] {
+				cutEdges = append(cutEdges, arc.edge)
+			}
+		}
+	}
+	return len(cutEdges), sortedEdges(cutEdges), nil
+}
+
+// flowNetwork builds the residual network of the graph with
+// unit capacities, where vertices are numbered in ID order.
+// Disabled edges and self-loops are left out.
+func (g *Graph) flowNetwork() (map[Vertex]int, [][]flowArc) {
+	vertices := g.Vertices()
+	index := make(map[Vertex]int, len(vertices))
+	for i, v := range vertices {
+		index[v] = i
+	}
+	//Costs are bounded so that no path cost overflows int64
+	maxCost := int64(math.MaxInt64 / (len(vertices) + 1))
+	network := make([][]flowArc, len(vertices))
+	for _, v := range vertices {
+		for _, edge := range g.VertexSet[v] {
+			u, w := index[edge.From], index[edge.To]
+			if edge.Disabled || u == w {
+				continue
+			}
+			cost := maxCost
+			if edge.Cost < uint64(maxCost) {
+				cost = int64(edge.Cost)
+			}
+			network[u] = append(network[u], flowArc{to: w, rev: len(network[w]), capacity: 1, cost: cost, edge: edge, forward: true})
+			network[w] = append(network[w], flowArc{to: u, rev: len(network[u]) - 1, cost: -cost})
+		}
+	}
+	return index, network
+}
+
+// augmentShortestPath pushes one unit of flow along the cheapest
+// path of the residual network, found by the Bellman-Ford algorithm
+// since residual arcs can have negative cost. It returns false if
+// the sink cannot be reached.
+func augmentShortestPath(network [][]flowArc, source, sink int) bool {
+	dist := make([]int64, len(network))
+	for i := range dist {
+		dist[i] = math.MaxInt64
+	}
+	type arcRef struct{ from, index int }
+	parent := make([]arcRef, len(network))
+	dist[source] = 0
+	for round := 0; round < len(network)-1; round++ {
+		changed := false
+		for u := range network {
+			if dist[u] == math.MaxInt64 {
+				continue
+			}
+			for i, arc := range network[u] {
+				if arc.capacity > 0 && dist[u]+arc.cost < dist[arc.to] {
+					dist[arc.to] = dist[u] + arc.cost
+					parent[arc.to] = arcRef{from: u, index: i}
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	if dist[sink] == math.MaxInt64 {
+		return false
+	}
+	for v := sink; v != source; {
+		ref := parent[v]
+		arc := &network[ref.from][ref.index]
+		arc.capacity--
+		network[v][arc.rev].capacity++
+		v = ref.from
+	}
+	return true
+}
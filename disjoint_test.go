@@ -0,0 +1,191 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEdgeDisjointPaths(t *testing.T) {
+	s := cspf.Vertex{ID: "s"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	dst := cspf.Vertex{ID: "t"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a graph with edge connectivity 3", t, func() {
+		So(graph.AddEdge(s, a, 1), ShouldBeNil)
+		So(graph.AddEdge(s, b, 1), ShouldBeNil)
+		So(graph.AddEdge(s, c, 1), ShouldBeNil)
+		So(graph.AddEdge(a, dst, 1), ShouldBeNil)
+		So(graph.AddEdge(a, d, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, dst, 1), ShouldBeNil)
+		So(graph.AddEdge(c, dst, 5), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+	})
+
+	Convey("Asking for 4 paths gives the 3 that exist", t, func() {
+		paths, err := graph.EdgeDisjointPaths(s, dst, 4)
+		So(err, ShouldBeNil)
+		var rendered []string
+		for _, path := range paths {
+			rendered = append(rendered, cspf.Path(path).String())
+		}
+		So(rendered, ShouldResemble, []string{"s->a->t", "s->b->d->t", "s->c->t"})
+	})
+
+	Convey("The paths are the cheapest disjoint ones", t, func() {
+		paths, err := graph.EdgeDisjointPaths(s, dst, 2)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 2)
+		So(cspf.Path(paths[0]).Cost()+cspf.Path(paths[1]).Cost(), ShouldEqual, 5)
+	})
+
+	Convey("Shortest augmenting paths undo greedy choices", t, func() {
+		//The shortest path x->y->z->w blocks any second path,
+		//while x->y->w and x->z->w are disjoint
+		x := cspf.Vertex{ID: "x"}
+		y := cspf.Vertex{ID: "y"}
+		z := cspf.Vertex{ID: "z"}
+		w := cspf.Vertex{ID: "w"}
+		trap := cspf.Graph{}
+		So(trap.AddEdge(x, y, 1), ShouldBeNil)
+		So(trap.AddEdge(y, z, 1), ShouldBeNil)
+		So(trap.AddEdge(z, w, 1), ShouldBeNil)
+		So(trap.AddEdge(x, z, 3), ShouldBeNil)
+		So(trap.AddEdge(y, w, 3), ShouldBeNil)
+		paths, err := trap.EdgeDisjointPaths(x, w, 2)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 2)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "x->y->w")
+		So(cspf.Path(paths[1]).String(), ShouldEqual, "x->z->w")
+	})
+
+	Convey("Unknown vertices have no paths", t, func() {
+		paths, err := graph.EdgeDisjointPaths(s, cspf.Vertex{ID: "unknown"}, 2)
+		So(err, ShouldBeNil)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("Call EdgeDisjointPaths on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.EdgeDisjointPaths(s, dst, 2)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestMinCostDisjointPair(t *testing.T) {
+	s := cspf.Vertex{ID: "s"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a trap topology for the greedy approach", t, func() {
+		So(graph.AddEdge(s, a, 1), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(s, b, 3), ShouldBeNil)
+		So(graph.AddEdge(a, d, 3), ShouldBeNil)
+	})
+
+	Convey("The greedy approach finds no disjoint second path", t, func() {
+		spf, err := graph.SPF(s, d)
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(s, d), ShouldResemble, []string{"s->a->b->d"})
+		//Only the edges off the shortest path are left
+		residual := cspf.Graph{}
+		So(residual.AddEdge(s, b, 3), ShouldBeNil)
+		So(residual.AddEdge(a, d, 3), ShouldBeNil)
+		So(residual.PathStrings(s, d), ShouldBeEmpty)
+	})
+
+	Convey("The optimal pair is found where the greedy second path fails", t, func() {
+		first, second, cost, err := graph.MinCostDisjointPair(s, d)
+		So(err, ShouldBeNil)
+		So(cspf.Path(first).String(), ShouldEqual, "s->a->d")
+		So(cspf.Path(second).String(), ShouldEqual, "s->b->d")
+		So(cost, ShouldEqual, 8)
+	})
+
+	Convey("A single path is not a pair", t, func() {
+		first, second, cost, err := graph.MinCostDisjointPair(a, b)
+		So(err, ShouldBeNil)
+		So(first, ShouldBeEmpty)
+		So(second, ShouldBeEmpty)
+		So(cost, ShouldEqual, cspf.Unreachable)
+	})
+
+	Convey("Call MinCostDisjointPair on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, _, err := nilGraph.MinCostDisjointPair(s, d)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestMinCut(t *testing.T) {
+	s := cspf.Vertex{ID: "s"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	z := cspf.Vertex{ID: "z"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate two well-connected halves joined by two links", t, func() {
+		for _, edge := range [][2]cspf.Vertex{{s, a}, {s, b}, {a, b}, {b, a}, {s, a}} {
+			So(graph.AddEdge(edge[0], edge[1], 1), ShouldBeNil)
+		}
+		//The two links between the halves
+		So(graph.AddEdge(a, c, 5), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		for _, edge := range [][2]cspf.Vertex{{c, z}, {d, z}, {c, d}, {d, c}, {c, z}} {
+			So(graph.AddEdge(edge[0], edge[1], 1), ShouldBeNil)
+		}
+	})
+
+	Convey("The minimum cut is made of the two links", t, func() {
+		value, cut, err := graph.MinCut(s, z)
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, 2)
+		So(cut, ShouldResemble, []cspf.Edge{
+			{From: a, To: c, Cost: 5},
+			{From: b, To: d, Cost: 1},
+		})
+		paths, err := graph.EdgeDisjointPaths(s, z, 10)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, value)
+	})
+
+	Convey("Removing the cut disconnects the vertices", t, func() {
+		_, cut, err := graph.MinCut(s, z)
+		So(err, ShouldBeNil)
+		cutGraph, err := graph.RerouteAvoiding(s, z, cut)
+		So(err, ShouldBeNil)
+		So(cutGraph.Paths(s, z), ShouldBeEmpty)
+	})
+
+	Convey("Disconnected vertices have an empty cut", t, func() {
+		value, cut, err := graph.MinCut(z, s)
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, 0)
+		So(cut, ShouldBeEmpty)
+		value, _, err = graph.MinCut(s, s)
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, 0)
+	})
+
+	Convey("Call MinCut on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, err := nilGraph.MinCut(s, z)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
@@ -0,0 +1,623 @@
+package cspf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Attributes of DOT edges with a special meaning.
+// All the other attributes are tags.
+const (
+	dotCost     = "cost"
+	dotWeight   = "weight"
+	dotID       = "id"
+	dotDisabled = "disabled"
+)
+
+// dotColor is the attribute ToDOTColored colors the edges with.
+// LoadDOT reads it as a tag like any other attribute.
+const dotColor = "color"
+
+// dotDefaultCost is the cost of the edges that
+// LoadDOT reads with no cost nor weight attribute.
+const dotDefaultCost = 1
+
+// ToDOT writes the graph in the GraphViz DOT language, as a
+// digraph where every vertex is a node named after its ID and
+// every edge carries its cost and its tags as attributes.
+// The ID and the state of edges are written as the id and
+// disabled attributes when set.
+//
+// Tag values are written in the same canonical encoding as
+// in the JSON format, but DOT carries no types: LoadDOT reads
+// them back with the same type if they are strings, bools, ints
+// or finite float64 values. Other integers are read back as int,
+// float32 values as float64, and values of any other type are
+// written as strings.
+// The tag keys cost, weight, id and disabled are reserved:
+// ToDOT fails with ErrReservedTagKey, writing nothing, if any
+// edge carries one of them.
+// The default tags of the vertices are not written, so they
+// are lost through LoadDOT: use MarshalJSON or WriteTo to
+// persist them.
+func (g *Graph) ToDOT(w io.Writer) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	return g.writeDOT(w, nil)
+}
+
+// dotDefaultColors are assigned, in order, to the tag values
+// the palette of ToDOTColored does not map.
+var dotDefaultColors = []string{
+	"blue", "red", "green", "orange", "purple",
+	"brown", "cyan", "magenta", "gold", "gray",
+}
+
+// ToDOTColored writes the graph like ToDOT does, coloring
+// every edge after the value of its colorTag tag: values the
+// palette maps are given their color, while the others are
+// given default colors, distinct from the ones of the palette
+// as long as there are enough of them, in the order of the
+// values. Edges without the tag are not colored.
+// The color is written as the color attribute of the edge,
+// in place of a color tag, so LoadDOT reads it back as a tag.
+func (g *Graph) ToDOTColored(w io.Writer, colorTag string, palette map[interface{}]string) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	colors := make(map[string]string)
+	used := make(map[string]bool)
+	for _, color := range palette {
+		used[color] = true
+	}
+	//Distinct values are told apart by type and by value,
+	//like tagsString does, so that they can be sorted
+	var unmapped []string
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			value, ok := edge.Tags[colorTag]
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("%T(%#v)", value, value)
+			if _, ok := colors[key]; ok {
+				continue
+			}
+			colors[key] = ""
+			if reflect.TypeOf(value) == nil || reflect.TypeOf(value).Comparable() {
+				if color, ok := palette[value]; ok {
+					colors[key] = color
+					continue
+				}
+			}
+			unmapped = append(unmapped, key)
+		}
+	}
+	sort.Strings(unmapped)
+	defaults := []string{}
+	for _, color := range dotDefaultColors {
+		if !used[color] {
+			defaults = append(defaults, color)
+		}
+	}
+	if len(defaults) == 0 {
+		defaults = dotDefaultColors
+	}
+	for i, key := range unmapped {
+		colors[key] = defaults[i%len(defaults)]
+	}
+
+	return g.writeDOT(w, func(edge Edge) string {
+		value, ok := edge.Tags[colorTag]
+		if !ok {
+			return ""
+		}
+		return colors[fmt.Sprintf("%T(%#v)", value, value)]
+	})
+}
+
+// writeDOT writes the graph in the DOT language, giving every
+// edge the color returned by color, if not nil nor empty.
+func (g *Graph) writeDOT(w io.Writer, color func(Edge) string) error {
+	for _, v := range g.Vertices() {
+		for _, edge := range g.VertexSet[v] {
+			for _, key := range sortedKeys(edge.Tags) {
+				switch key {
+				case dotCost, dotWeight, dotID, dotDisabled:
+					return fmt.Errorf("%w: %s on edge %s->%s", ErrReservedTagKey, key, edge.From.ID, edge.To.ID)
+				}
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph {")
+	for _, v := range g.Vertices() {
+		fmt.Fprintf(bw, "\t%s;\n", dotQuote(v.ID))
+	}
+	for _, v := range g.Vertices() {
+		for _, edge := range g.VertexSet[v] {
+			attrs := []string{dotCost + "=" + strconv.FormatUint(edge.Cost, 10)}
+			if edge.ID != "" {
+				attrs = append(attrs, dotID+"="+dotQuote(edge.ID))
+			}
+			if edge.Disabled {
+				attrs = append(attrs, dotDisabled+"=true")
+			}
+			edgeColor := ""
+			if color != nil {
+				edgeColor = color(edge)
+			}
+			for _, key := range sortedKeys(edge.Tags) {
+				if key == dotColor && edgeColor != "" {
+					continue
+				}
+				attrs = append(attrs, dotQuote(key)+"="+dotValue(edge.Tags[key]))
+			}
+			if edgeColor != "" {
+				attrs = append(attrs, dotColor+"="+dotQuote(edgeColor))
+			}
+			fmt.Fprintf(bw, "\t%s -> %s [%s];\n", dotQuote(edge.From.ID), dotQuote(edge.To.ID), strings.Join(attrs, ", "))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotQuote renders a string as a quoted DOT ID.
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+// dotValue renders a tag value as a DOT ID, in its canonical
+// encoding, unquoted for the types LoadDOT must not read as strings.
+func dotValue(value interface{}) string {
+	t, text, err := formatTag(value)
+	switch {
+	case err != nil:
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(v.Uint(), 10)
+		}
+		return dotQuote(fmt.Sprint(value))
+	case t == tagNil:
+		return dotQuote(fmt.Sprint(value))
+	case t == tagString:
+		return dotQuote(text)
+	case t == tagFloat32 || t == tagFloat64:
+		return dotFloat(text)
+	}
+	return text
+}
+
+// dotFloat marks a float so that it is not read back as an int.
+func dotFloat(s string) string {
+	if !strings.ContainsAny(s, ".eEnN") {
+		s += ".0"
+	}
+	return s
+}
+
+// LoadDOT reads a graph written in the GraphViz DOT language.
+// Nodes become vertices with the same ID and edges become
+// edges with the cost given by their cost or weight attribute,
+// or 1 if they have neither. The id and disabled attributes set
+// the ID and the state of the edge, and all the other attributes
+// become tags. Edge IDs must be unique: an id attribute given to
+// a statement that creates more than one edge, or to an edge
+// statement following an edge with the same ID, makes LoadDOT
+// fail with ErrInvalidDOT. Default attributes set by edge statements apply
+// to the edges that follow them.
+//
+// Quoted attribute values are read as strings, while unquoted
+// ones are read as bools (true and false), ints or float64
+// values if they have that syntax, and as strings otherwise.
+// Edges of undirected graphs, written with --, are added
+// in both directions.
+//
+// Only a subset of the language is supported: subgraphs,
+// ports and HTML strings make LoadDOT fail with ErrInvalidDOT,
+// as does any syntax error. Graph and node attributes are
+// accepted but ignored.
+func LoadDOT(r io.Reader) (*Graph, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := dotTokenize(string(input))
+	if err != nil {
+		return nil, err
+	}
+	p := dotParser{tokens: tokens, graph: &Graph{}}
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+	return p.graph, nil
+}
+
+// dotToken is a lexical token of the DOT language.
+type dotToken struct {
+	text string
+	// Quoted tells quoted IDs from the rest.
+	quoted bool
+	line   int
+}
+
+// isID reports whether the token is an ID.
+func (t dotToken) isID() bool {
+	if t.quoted {
+		return true
+	}
+	switch t.text {
+	case "{", "}", "[", "]", "=", ";", ",", "->", "--", ":", "<":
+		return false
+	}
+	return true
+}
+
+// dotTokenize splits DOT input into tokens,
+// dropping whitespace and comments.
+func dotTokenize(input string) ([]dotToken, error) {
+	var tokens []dotToken
+	runes := []rune(input)
+	line := 1
+	atLineStart := true
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			atLineStart = true
+			i++
+			continue
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '#' && atLineStart:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: line %d: unterminated comment", ErrInvalidDOT, line)
+			}
+			i += 2
+			continue
+		}
+		atLineStart = false
+
+		switch {
+		case c == '"':
+			var b strings.Builder
+			start := line
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				} else if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\n' {
+					//Escaped newlines continue the string
+					i++
+					line++
+					continue
+				} else if runes[i] == '\n' {
+					line++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: line %d: unterminated string", ErrInvalidDOT, start)
+			}
+			i++
+			tokens = append(tokens, dotToken{text: b.String(), quoted: true, line: start})
+		case c == '-' && i+1 < len(runes) && (runes[i+1] == '>' || runes[i+1] == '-'):
+			tokens = append(tokens, dotToken{text: string(runes[i : i+2]), line: line})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:<", c):
+			tokens = append(tokens, dotToken{text: string(c), line: line})
+			i++
+		case c == '_' || c == '-' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '-' || runes[i] == '.' ||
+				unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				if runes[i] == '-' && i+1 < len(runes) && (runes[i+1] == '>' || runes[i+1] == '-') {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, dotToken{text: string(runes[start:i]), line: line})
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected character %q", ErrInvalidDOT, line, c)
+		}
+	}
+	return tokens, nil
+}
+
+// dotParser builds a graph out of DOT tokens.
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *Graph
+	// Undirected graphs use -- as edge operator.
+	undirected bool
+	// Default attributes of the following edges.
+	edgeDefaults []dotAttr
+}
+
+// dotAttr is an attribute of a DOT statement.
+type dotAttr struct {
+	key   string
+	value dotToken
+}
+
+func (p *dotParser) peek() (dotToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return dotToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *dotParser) next() (dotToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return dotToken{}, p.errorf("unexpected end of input")
+	}
+	p.pos++
+	return t, nil
+}
+
+// accept consumes the next token if it is the given keyword or punctuation.
+func (p *dotParser) accept(text string) bool {
+	t, ok := p.peek()
+	if ok && !t.quoted && strings.EqualFold(t.text, text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *dotParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func (p *dotParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidDOT, line, fmt.Sprintf(format, args...))
+}
+
+// parseGraph parses: [strict] (graph | digraph) [ID] { stmt_list }
+func (p *dotParser) parseGraph() error {
+	p.accept("strict")
+	switch {
+	case p.accept("digraph"):
+	case p.accept("graph"):
+		p.undirected = true
+	default:
+		return p.errorf("expected graph or digraph")
+	}
+	if t, ok := p.peek(); ok && t.isID() {
+		p.pos++
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if err := p.parseStatement(); err != nil {
+			return err
+		}
+		p.accept(";")
+	}
+	if _, ok := p.peek(); ok {
+		return p.errorf("unexpected input after the graph")
+	}
+	return nil
+}
+
+// parseStatement parses a node, edge, attribute or ID = ID statement.
+func (p *dotParser) parseStatement() error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if !t.isID() {
+		return p.errorf("unexpected %q", t.text)
+	}
+	if !t.quoted {
+		switch strings.ToLower(t.text) {
+		case "subgraph":
+			return p.errorf("subgraphs are not supported")
+		case "graph", "node":
+			_, err := p.parseAttrLists()
+			return err
+		case "edge":
+			attrs, err := p.parseAttrLists()
+			p.edgeDefaults = append(p.edgeDefaults, attrs...)
+			return err
+		}
+	}
+	if p.accept("=") {
+		value, err := p.next()
+		if err != nil || !value.isID() {
+			return p.errorf("expected an ID after =")
+		}
+		return nil
+	}
+	if p.accept(":") {
+		return p.errorf("ports are not supported")
+	}
+
+	vertices := []Vertex{{ID: t.text}}
+	operator := "->"
+	if p.undirected {
+		operator = "--"
+	}
+	for {
+		next, ok := p.peek()
+		if !ok || next.quoted || (next.text != "->" && next.text != "--") {
+			break
+		}
+		if next.text != operator {
+			return p.errorf("%s is not allowed in this graph", next.text)
+		}
+		p.pos++
+		to, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !to.isID() {
+			return p.errorf("expected a node ID after %s", operator)
+		}
+		vertices = append(vertices, Vertex{ID: to.text})
+	}
+	attrs, err := p.parseAttrLists()
+	if err != nil {
+		return err
+	}
+	if len(vertices) == 1 {
+		p.graph.AddNode(vertices[0])
+		return nil
+	}
+	edge, err := p.edge(append(append([]dotAttr{}, p.edgeDefaults...), attrs...))
+	if err != nil {
+		return err
+	}
+	if edge.ID != "" {
+		//IDs must stay unique, so they cannot be
+		//shared by the edges of a chain
+		if len(vertices) > 2 || (p.undirected && vertices[0] != vertices[1]) {
+			return p.errorf("id %q would be given to more than one edge", edge.ID)
+		}
+		if _, _, found := p.graph.findEdgeByID(edge.ID); found {
+			return p.errorf("duplicate id %q", edge.ID)
+		}
+	}
+	for i := 1; i < len(vertices); i++ {
+		p.graph.addEdge(edge.between(vertices[i-1], vertices[i]))
+		if p.undirected && vertices[i-1] != vertices[i] {
+			p.graph.addEdge(edge.between(vertices[i], vertices[i-1]))
+		}
+	}
+	return nil
+}
+
+// between returns a copy of the edge, with its own
+// tags, that connects the given vertices.
+func (e Edge) between(from, to Vertex) Edge {
+	e.From, e.To = from, to
+	if e.Tags != nil {
+		tags := make(map[string]interface{}, len(e.Tags))
+		for key, value := range e.Tags {
+			tags[key] = value
+		}
+		e.Tags = tags
+	}
+	return e
+}
+
+// parseAttrLists parses any number of [ a_list ].
+func (p *dotParser) parseAttrLists() ([]dotAttr, error) {
+	var attrs []dotAttr
+	for p.accept("[") {
+		for !p.accept("]") {
+			key, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if !key.isID() {
+				return nil, p.errorf("expected an attribute name")
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if !value.isID() {
+				return nil, p.errorf("expected a value for attribute %s", key.text)
+			}
+			attrs = append(attrs, dotAttr{key: key.text, value: value})
+			if !p.accept(",") {
+				p.accept(";")
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// edge builds an edge, with no vertices, out of its attributes.
+// Later attributes override earlier ones with the same name.
+func (p *dotParser) edge(attrs []dotAttr) (Edge, error) {
+	edge := Edge{Cost: dotDefaultCost}
+	for _, attr := range attrs {
+		switch attr.key {
+		case dotCost, dotWeight:
+			cost, err := strconv.ParseUint(attr.value.text, 10, 64)
+			if err != nil {
+				return Edge{}, fmt.Errorf("%w: line %d: invalid %s %q", ErrInvalidDOT, attr.value.line, attr.key, attr.value.text)
+			}
+			edge.Cost = cost
+		case dotID:
+			edge.ID = attr.value.text
+		case dotDisabled:
+			disabled, err := strconv.ParseBool(attr.value.text)
+			if err != nil {
+				return Edge{}, fmt.Errorf("%w: line %d: invalid %s %q", ErrInvalidDOT, attr.value.line, attr.key, attr.value.text)
+			}
+			edge.Disabled = disabled
+		default:
+			if edge.Tags == nil {
+				edge.Tags = make(map[string]interface{})
+			}
+			edge.Tags[attr.key] = dotTagValue(attr.value)
+		}
+	}
+	return edge, nil
+}
+
+// dotTagValue converts an attribute value to a tag value.
+func dotTagValue(t dotToken) interface{} {
+	if t.quoted {
+		return t.text
+	}
+	if b, err := strconv.ParseBool(t.text); err == nil && (t.text == "true" || t.text == "false") {
+		return b
+	}
+	if i, err := strconv.Atoi(t.text); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return f
+	}
+	return t.text
+}
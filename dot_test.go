@@ -0,0 +1,162 @@
+package cspf_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDOTRoundTrip(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: `say "c"`}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with tags of several types", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "link", Value: "red"}, cspf.Tag{Key: "latency", Value: 10}), ShouldBeNil)
+		So(graph.AddEdge(b, c, 2, cspf.Tag{Key: "loss", Value: 0.5}, cspf.Tag{Key: "ratio", Value: 3.0}), ShouldBeNil)
+		So(graph.AddEdge(c, a, 3, cspf.Tag{Key: "monitored", Value: true}, cspf.Tag{Key: "note", Value: "42"}), ShouldBeNil)
+		So(graph.AddEdgeWithID("backup", a, b, 7), ShouldBeNil)
+		So(graph.SetEdgeEnabled(c, a, false), ShouldEqual, 1)
+		graph.AddNode(d)
+	})
+
+	Convey("ToDOT then LoadDOT produces an equal graph", t, func() {
+		var buf bytes.Buffer
+		So(graph.ToDOT(&buf), ShouldBeNil)
+		So(buf.String(), ShouldStartWith, "digraph {\n")
+		loaded, err := cspf.LoadDOT(&buf)
+		So(err, ShouldBeNil)
+		So(loaded.Equal(&graph), ShouldBeTrue)
+		So(loaded.VertexSet[a][1].ID, ShouldEqual, "backup")
+	})
+
+	Convey("Reserved tag keys are rejected", t, func() {
+		for _, key := range []string{"cost", "weight", "id", "disabled"} {
+			reserved := cspf.Graph{}
+			So(reserved.AddEdge(a, b, 1, cspf.Tag{Key: key, Value: 5}), ShouldBeNil)
+			var buf bytes.Buffer
+			err := reserved.ToDOT(&buf)
+			So(errors.Is(err, cspf.ErrReservedTagKey), ShouldBeTrue)
+			So(buf.Len(), ShouldEqual, 0)
+			err = reserved.ToDOTColored(&buf, "link", nil)
+			So(errors.Is(err, cspf.ErrReservedTagKey), ShouldBeTrue)
+		}
+	})
+
+	Convey("Call ToDOT on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.ToDOT(&bytes.Buffer{}), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestToDOTColored(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with red, blue and other links", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, cspf.Tag{Key: "link", Value: "green"}, cspf.Tag{Key: "color", Value: "ignored"}), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1, cspf.Tag{Key: "link", Value: 7}), ShouldBeNil)
+		So(graph.AddEdge(c, b, 1), ShouldBeNil)
+	})
+
+	Convey("Edges are colored after the palette and the defaults", t, func() {
+		var buf bytes.Buffer
+		palette := map[interface{}]string{"red": "red", "blue": "blue"}
+		So(graph.ToDOTColored(&buf, "link", palette), ShouldBeNil)
+		So(buf.String(), ShouldEqual, `digraph {
+	"a";
+	"b";
+	"c";
+	"a" -> "b" [cost=1, "link"="red", color="red"];
+	"a" -> "c" [cost=2, "link"="blue", color="blue"];
+	"b" -> "c" [cost=1, "link"="green", color="orange"];
+	"c" -> "a" [cost=1, "link"=7, color="green"];
+	"c" -> "b" [cost=1];
+}
+`)
+	})
+
+	Convey("Colored output is valid DOT", t, func() {
+		var buf bytes.Buffer
+		So(graph.ToDOTColored(&buf, "link", nil), ShouldBeNil)
+		loaded, err := cspf.LoadDOT(&buf)
+		So(err, ShouldBeNil)
+		//Default colors follow the order of the values,
+		//which are sorted by type first
+		So(loaded.VertexSet[c][0].Tags["color"], ShouldEqual, "blue")
+		So(loaded.VertexSet[a][1].Tags["color"], ShouldEqual, "red")
+		So(loaded.VertexSet[b][0].Tags["color"], ShouldEqual, "green")
+		So(loaded.VertexSet[a][0].Tags["color"], ShouldEqual, "orange")
+	})
+
+	Convey("Call ToDOTColored on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.ToDOTColored(&bytes.Buffer{}, "link", nil), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestLoadDOT(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	Convey("Load a hand-written digraph", t, func() {
+		loaded, err := cspf.LoadDOT(strings.NewReader(`
+			# Generated by hand
+			strict digraph network {
+				rankdir = LR; // Ignored
+				node [shape=box]
+				a -> b [weight=5, link=blue]
+				/* Default cost */
+				b -> c -> a
+				edge [link=red]
+				a -> c [cost=2 latency=1.5]
+			}`))
+		So(err, ShouldBeNil)
+		So(loaded.VertexSet[a], ShouldResemble, []cspf.Edge{
+			{From: a, To: b, Cost: 5, Tags: map[string]interface{}{"link": "blue"}},
+			{From: a, To: c, Cost: 2, Tags: map[string]interface{}{"link": "red", "latency": 1.5}},
+		})
+		So(loaded.VertexSet[b], ShouldResemble, []cspf.Edge{{From: b, To: c, Cost: 1}})
+		So(loaded.VertexSet[c], ShouldResemble, []cspf.Edge{{From: c, To: a, Cost: 1}})
+	})
+
+	Convey("Undirected edges are added in both directions", t, func() {
+		loaded, err := cspf.LoadDOT(strings.NewReader(`graph { a -- b [cost=3] }`))
+		So(err, ShouldBeNil)
+		So(loaded.EdgeCount(), ShouldEqual, 2)
+		So(loaded.VertexSet[b], ShouldResemble, []cspf.Edge{{From: b, To: a, Cost: 3}})
+	})
+
+	Convey("Invalid input is reported", t, func() {
+		for _, input := range []string{
+			``,
+			`digraph { a -> }`,
+			`digraph { a -- b }`,
+			`digraph { a -> b [cost=-1] }`,
+			`digraph { subgraph s { a } }`,
+			`digraph { "a }`,
+			`digraph { a } b`,
+			`digraph { a -> b [id=x]; b -> c [id=x] }`,
+			`digraph { a -> b -> c [id=x] }`,
+			`digraph { edge [id=x]; a -> b; b -> c }`,
+			`graph { a -- b [id=x] }`,
+		} {
+			_, err := cspf.LoadDOT(strings.NewReader(input))
+			So(errors.Is(err, cspf.ErrInvalidDOT), ShouldBeTrue)
+		}
+	})
+}
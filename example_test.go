@@ -23,7 +23,7 @@ func ExampleGraph() {
 	paths := graph.Paths(a, b)
 
 	fmt.Println(paths)
-	// Output: [[{{A} {B} 1 map[]}]]
+	// Output: [[{{A} {B} 1 map[]  false}]]
 }
 
 func ExampleGraph_SPF() {
@@ -53,7 +53,7 @@ func ExampleGraph_SPF() {
 	paths := spfGraph.Paths(a, d)
 
 	fmt.Println(paths)
-	// Output: [[{{A} {B} 1 map[]} {{B} {D} 1 map[]}]]
+	// Output: [[{{A} {B} 1 map[]  false} {{B} {D} 1 map[]  false}]]
 }
 
 func ExampleGraph_CSPF() {
@@ -95,5 +95,5 @@ func ExampleGraph_CSPF() {
 	paths := cspfGraph.Paths(a, e)
 
 	fmt.Println(paths)
-	// Output: [[{{A} {B} 2 map[link:red]} {{B} {C} 2 map[link:red]} {{C} {E} 2 map[link:red]}]]
+	// Output: [[{{A} {B} 2 map[link:red]  false} {{B} {C} 2 map[link:red]  false} {{C} {E} 2 map[link:red]  false}]]
 }
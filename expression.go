@@ -0,0 +1,267 @@
+package cspf
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaesslerAG/gval"
+)
+
+// language is the gval language CSPF expressions are
+// written in: the full gval language extended with
+// the functions below.
+//
+// between(value, lo, hi) is true if value falls within
+// the inclusive [lo, hi] range. Arguments are converted
+// to numbers the same way gval arithmetic does.
+//
+// matches(value, pattern) is true if the string value
+// matches the regular expression pattern. It is equivalent
+// to the gval operator value =~ pattern, which compiles
+// constant patterns once while parsing but recompiles
+// patterns that are not constant at every evaluation;
+// matches caches the compiled patterns instead, up to
+// maxCachedPatterns of them. Like =~, matches is false
+// for values that are not strings, such as missing tags.
+//
+// anyTag(value) is true if any tag of the edge, whatever
+// its key, has a value deeply equal to the given one.
+//
+// time(value), duration(value) and now() let expressions
+// compare timestamps and durations stored as strings, which
+// would otherwise be compared lexically, by converting them to
+// numbers of seconds: time parses an RFC 3339 timestamp, such
+// as "2006-01-02T15:04:05Z07:00" with optional fractional
+// seconds, into seconds since the Unix epoch; duration parses
+// a Go duration, such as "50ms" or "1h30m", into seconds; now
+// returns the current time in seconds since the Unix epoch.
+// For example, time(expiry) > now() selects the edges whose
+// expiry is in the future, and duration(latency) < duration("50ms")
+// the ones whose latency is below 50 milliseconds.
+var language = gval.Full(
+	gval.Function("between", between),
+	gval.Function("matches", matches),
+	gval.Function("time", parseTime),
+	gval.Function("duration", parseDuration),
+	gval.Function("now", now),
+	gval.VariableSelector(selectVariable),
+)
+
+// anyTagFunction is the name of the function that
+// receives the tags of the evaluated edge.
+const anyTagFunction = "anyTag"
+
+// selectVariable resolves the identifiers of an expression
+// against the tags of the evaluated edge, the same way gval
+// does. In addition, anyTag resolves to a function bound to
+// the tags, which is how anyTag(value) can inspect all of
+// them without knowing their keys.
+func selectVariable(path gval.Evaluables) gval.Evaluable {
+	return func(c context.Context, parameter interface{}) (interface{}, error) {
+		keys, err := path.EvalStrings(c, parameter)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 1 && keys[0] == anyTagFunction {
+			tags, _ := parameter.(map[string]interface{})
+			return func(value interface{}) bool {
+				for _, tagValue := range tags {
+					if reflect.DeepEqual(tagValue, value) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		}
+
+		value := parameter
+		for i, key := range keys {
+			switch o := value.(type) {
+			case map[string]interface{}:
+				value = o[key]
+			case map[interface{}]interface{}:
+				value = o[key]
+			default:
+				var ok bool
+				value, ok = selectField(key, value)
+				if !ok {
+					return nil, fmt.Errorf("unknown parameter %s", strings.Join(keys[:i+1], "."))
+				}
+			}
+		}
+		return value, nil
+	}
+}
+
+// selectField selects the element with the given key out of
+// a map, a slice or a struct through reflection.
+func selectField(key string, value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	elem := v
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.Map:
+		if elem.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		item := elem.MapIndex(reflect.ValueOf(key).Convert(elem.Type().Key()))
+		if item.IsValid() {
+			return item.Interface(), true
+		}
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && elem.Len() > i {
+			return elem.Index(i).Interface(), true
+		}
+	case reflect.Struct:
+		if field := elem.FieldByName(key); field.IsValid() && field.CanInterface() {
+			return field.Interface(), true
+		}
+		if method := v.MethodByName(key); method.IsValid() {
+			return method.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// maxCachedPatterns bounds the number of regular expressions
+// matches keeps compiled, so that expressions building their
+// patterns dynamically cannot grow the cache without limit.
+const maxCachedPatterns = 1024
+
+// patternCache holds the regular expressions compiled by matches.
+// It is emptied when it is full.
+var patternCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// matches takes its arguments untyped, so that gval passes
+// it the values that are not strings, nil included, instead
+// of failing the call.
+func matches(arguments ...interface{}) (interface{}, error) {
+	if len(arguments) != 2 {
+		return nil, fmt.Errorf("matches: expected 2 arguments, got %d", len(arguments))
+	}
+	pattern, ok := arguments[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("matches: pattern %v (%T) is not a string", arguments[1], arguments[1])
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := arguments[0].(string)
+	return ok && re.MatchString(value), nil
+}
+
+// compilePattern compiles a regular expression through patternCache.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternCache.Lock()
+	re, ok := patternCache.patterns[pattern]
+	patternCache.Unlock()
+	if ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Lock()
+	if len(patternCache.patterns) >= maxCachedPatterns {
+		patternCache.patterns = make(map[string]*regexp.Regexp)
+	}
+	patternCache.patterns[pattern] = re
+	patternCache.Unlock()
+	return re, nil
+}
+
+func parseTime(value string) (float64, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, err
+	}
+	return unixSeconds(t), nil
+}
+
+func parseDuration(value string) (float64, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
+
+func now() float64 {
+	return unixSeconds(time.Now())
+}
+
+// unixSeconds returns the seconds elapsed since
+// the Unix epoch, including the fraction.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func between(value, lo, hi interface{}) (bool, error) {
+	v, err := numericArgument("between", value)
+	if err != nil {
+		return false, err
+	}
+	l, err := numericArgument("between", lo)
+	if err != nil {
+		return false, err
+	}
+	h, err := numericArgument("between", hi)
+	if err != nil {
+		return false, err
+	}
+	return l <= v && v <= h, nil
+}
+
+func numericArgument(function string, value interface{}) (float64, error) {
+	f, ok := toFloat64(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s argument %v (%T)", ErrNotNumeric, function, value, value)
+	}
+	return f, nil
+}
+
+// toFloat64 converts a tag value to float64, following
+// the same conversion rules gval applies to the operands
+// of arithmetic operators: any int, uint or float type
+// is converted, and strings are parsed.
+func toFloat64(value interface{}) (float64, bool) {
+	if f, ok := value.(float64); ok {
+		return f, true
+	}
+	v := reflect.ValueOf(value)
+	for value != nil && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+		value = v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,322 @@
+package cspf_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSPFBetween(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with numeric tags", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "bw", Value: 1}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "bw", Value: uint8(10)}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "bw", Value: 2.0}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "bw", Value: "5"}), ShouldBeNil)
+	})
+
+	Convey("Select the edges whose tag falls within an inclusive range", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `between(bw, 2, 5)`)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Widen the range to select the cheapest path", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `between(bw, 1, 10)`)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d")
+	})
+
+	Convey("A non-numeric value is reported as an error", t, func() {
+		_, err := graph.CSPF(a, d, `between(bw, "low", 10)`)
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+	})
+}
+
+// semverGt reports whether version a is greater than version b,
+// both in major.minor.patch form.
+func semverGt(a, b string) (bool, error) {
+	var va, vb [3]int
+	if _, err := fmt.Sscanf(a, "%d.%d.%d", &va[0], &va[1], &va[2]); err != nil {
+		return false, err
+	}
+	if _, err := fmt.Sscanf(b, "%d.%d.%d", &vb[0], &vb[1], &vb[2]); err != nil {
+		return false, err
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i], nil
+		}
+	}
+	return false, nil
+}
+
+func TestCSPFWithFunction(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with version tags", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "version", Value: "1.9.0"}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "version", Value: "1.10.0"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "version", Value: "1.10.2"}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "version", Value: "2.0.0"}), ShouldBeNil)
+	})
+
+	Convey("A lexical comparison selects the wrong versions", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `version > "1.9.5"`)
+		So(err, ShouldBeNil)
+		So(len(cspfGraph.Paths(a, d)), ShouldEqual, 0)
+	})
+
+	Convey("A custom function compares the versions semantically", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `semverGt(version, "1.9.5")`,
+			cspf.WithFunction("semverGt", semverGt))
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("An unknown function cannot be called", t, func() {
+		_, err := graph.CSPF(a, d, `semverGt(version, "1.9.5")`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Errors of the custom function abort the query", t, func() {
+		_, err := graph.CSPF(a, d, `semverGt(version, "latest")`,
+			cspf.WithFunction("semverGt", semverGt))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestCSPFWithEvalTimeout(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	//The custom function hangs on the slow edges until released
+	release := make(chan struct{})
+	defer close(release)
+	check := func(speed string) bool {
+		if speed == "slow" {
+			<-release
+		}
+		return true
+	}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "speed", Value: "fast"}), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1, cspf.Tag{Key: "speed", Value: "slow"}), ShouldBeNil)
+	})
+
+	Convey("Evaluations within the timeout succeed", t, func() {
+		cspfGraph, err := graph.CSPF(a, b, `check(speed)`,
+			cspf.WithFunction("check", check), cspf.WithEvalTimeout(time.Second))
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, b), ShouldResemble, []string{"a->b"})
+	})
+
+	Convey("A hanging evaluation fires the timeout", t, func() {
+		start := time.Now()
+		_, err := graph.CSPF(c, b, `check(speed)`,
+			cspf.WithFunction("check", check), cspf.WithEvalTimeout(20*time.Millisecond))
+		So(errors.Is(err, cspf.ErrEvalTimeout), ShouldBeTrue)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
+	})
+}
+
+func TestCSPFRegularExpressions(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with name tags", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "name", Value: "edge-1"}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "name", Value: "core-1"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "name", Value: "core-2"}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "name", Value: "core-3"}), ShouldBeNil)
+	})
+
+	Convey("Select the edges whose name matches a pattern", t, func() {
+		for _, exp := range []string{`matches(name, "^core-")`, `name =~ "^core-"`} {
+			cspfGraph, err := graph.CSPF(a, d, exp)
+			So(err, ShouldBeNil)
+			paths := cspfGraph.Paths(a, d)
+			So(len(paths), ShouldEqual, 1)
+			So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		}
+	})
+
+	Convey("An invalid pattern is reported as an error", t, func() {
+		_, err := graph.CSPF(a, d, `matches(name, "(core")`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Values that are not strings do not match", t, func() {
+		So(graph.AddEdge(a, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, d, 1, cspf.Tag{Key: "name", Value: 1}), ShouldBeNil)
+		for _, exp := range []string{`matches(name, "^core-")`, `name =~ "^core-"`} {
+			cspfGraph, err := graph.CSPF(a, d, exp)
+			So(err, ShouldBeNil)
+			So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		}
+	})
+}
+
+func TestCSPFAnyTag(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with red tags under varying keys", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "color", Value: "blue"}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "color", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "layer", Value: "red"}, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+	})
+
+	Convey("Select the edges with any tag equal to red", t, func() {
+		result, err := graph.CSPFDetailed(a, d, `anyTag("red")`)
+		So(err, ShouldBeNil)
+		So(result.Graph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		So(len(result.MatchedEdges), ShouldEqual, 3)
+		So(len(result.PrunedEdges), ShouldEqual, 1)
+	})
+
+	Convey("Combine anyTag with regular tag lookups", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `anyTag("red") && link != "blue"`)
+		So(err, ShouldBeNil)
+		So(len(cspfGraph.Paths(a, d)), ShouldEqual, 0)
+		cspfGraph, err = graph.CSPF(a, d, `anyTag("blue") || link == "red"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+	})
+
+	Convey("Nested tag values can still be selected", t, func() {
+		nested := cspf.Graph{}
+		So(nested.AddEdge(a, b, 1, cspf.Tag{Key: "site", Value: map[string]interface{}{"region": "eu"}}), ShouldBeNil)
+		cspfGraph, err := nested.CSPF(a, b, `site.region == "eu"`)
+		So(err, ShouldBeNil)
+		So(len(cspfGraph.Paths(a, b)), ShouldEqual, 1)
+	})
+}
+
+func TestCSPFNumericTagNormalizer(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	latency := func(value interface{}, unit string) []cspf.Tag {
+		return []cspf.Tag{{Key: "latency", Value: value}, {Key: "unit", Value: unit}}
+	}
+	//Convert every latency to milliseconds
+	toMillis := func(key string, value interface{}, tags map[string]interface{}) float64 {
+		f := 0.0
+		switch v := value.(type) {
+		case int:
+			f = float64(v)
+		case float64:
+			f = v
+		}
+		if key == "latency" && tags["unit"] == "us" {
+			return f / 1000
+		}
+		return f
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph mixing units", t, func() {
+		So(graph.AddEdge(a, b, 1, latency(2, "ms")...), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, latency(3000, "us")...), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, latency(1500, "us")...), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, latency(2.5, "ms")...), ShouldBeNil)
+	})
+
+	Convey("Without normalization microseconds look larger", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `latency <= 2.5`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.Paths(a, d), ShouldBeEmpty)
+	})
+
+	Convey("The threshold applies uniformly to normalized tags", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `latency <= 2.5`,
+			cspf.WithNumericTagNormalizer(toMillis))
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		//The graph keeps the original values
+		So(graph.VertexSet[a][1].Tags["latency"], ShouldEqual, 1500)
+	})
+}
+
+func TestCSPFTimesAndDurations(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	future := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	past := "2001-02-03T04:05:06.789+02:00"
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with expiry and latency tags", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "expiry", Value: past}, cspf.Tag{Key: "latency", Value: "9ms"}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "expiry", Value: future}, cspf.Tag{Key: "latency", Value: "80ms"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "expiry", Value: future}, cspf.Tag{Key: "latency", Value: "1500us"}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "expiry", Value: future}, cspf.Tag{Key: "latency", Value: "0.01s"}), ShouldBeNil)
+	})
+
+	Convey("Select the edges whose expiry is in the future", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `time(expiry) > now()`)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Compare durations regardless of their unit", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `duration(latency) < duration("50ms")`)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Invalid timestamps are reported as errors", t, func() {
+		_, err := graph.CSPF(a, d, `time(latency) > now()`)
+		So(err, ShouldNotBeNil)
+		_, err = graph.CSPF(a, d, `duration(expiry) > 0`)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,76 @@
+package cspf
+
+import "sort"
+
+// ForwardingTable expresses the shortest paths from the given
+// vertex as forwarding rules: for every vertex along those
+// paths, it maps the ID of each destination to the IDs of the
+// neighbors the vertex must forward to in order to reach the
+// destination along a shortest path from the source.
+// With equal-cost paths, all the next hops are listed, sorted
+// by ID. Vertices with no rules are left out of the table.
+func (g *Graph) ForwardingTable(from Vertex) (map[string]map[string][]string, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]map[string][]string)
+	for dest := range distSet {
+		if dest == from {
+			continue
+		}
+		//Walk the predecessors back from the destination:
+		//every edge met is a next hop towards it
+		nextHops := make(map[Vertex]map[Vertex]bool)
+		visited := map[Vertex]bool{dest: true}
+		pending := []Vertex{dest}
+		for len(pending) > 0 {
+			v := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			for _, edge := range prevSet[v] {
+				if nextHops[edge.From] == nil {
+					nextHops[edge.From] = make(map[Vertex]bool)
+				}
+				nextHops[edge.From][edge.To] = true
+				if !visited[edge.From] {
+					visited[edge.From] = true
+					pending = append(pending, edge.From)
+				}
+			}
+		}
+		for v, hops := range nextHops {
+			ids := make([]string, 0, len(hops))
+			for hop := range hops {
+				ids = append(ids, hop.ID)
+			}
+			sort.Strings(ids)
+			if table[v.ID] == nil {
+				table[v.ID] = make(map[string][]string)
+			}
+			table[v.ID][dest.ID] = ids
+		}
+	}
+	return table, nil
+}
+
+// NextHops returns the vertices a packet visits after the
+// source along a single shortest path from one vertex to
+// another, in order and ending with the destination.
+// Among equal-cost paths, every vertex is reached through its
+// smallest predecessor edge, like PathIterator does first.
+// The list is empty if the destination cannot be reached or
+// is the source itself.
+func (g *Graph) NextHops(from, to Vertex) ([]Vertex, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	path, ok := g.shortestPath(from, to, newQuery(nil))
+	if !ok {
+		return []Vertex{}, nil
+	}
+	return path.Vertices()[1:], nil
+}
@@ -0,0 +1,98 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestForwardingTable(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the diamond graph", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("Derive the forwarding rules from a", t, func() {
+		table, err := graph.ForwardingTable(a)
+		So(err, ShouldBeNil)
+		So(table, ShouldResemble, map[string]map[string][]string{
+			"a": {
+				"b": {"b"},
+				"c": {"c"},
+				//Equal-cost paths list all the next hops
+				"d": {"b", "c"},
+			},
+			"b": {"d": {"d"}},
+			"c": {"d": {"d"}},
+		})
+	})
+
+	Convey("A vertex that reaches nothing has an empty table", t, func() {
+		table, err := graph.ForwardingTable(d)
+		So(err, ShouldBeNil)
+		So(table, ShouldBeEmpty)
+	})
+
+	Convey("Call ForwardingTable on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.ForwardingTable(a)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestNextHops(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	x := cspf.Vertex{ID: "X"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a chain with an equal-cost detour", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, e, 1), ShouldBeNil)
+		//B -> X -> D costs the same as B -> C -> D
+		So(graph.AddEdge(b, x, 1), ShouldBeNil)
+		So(graph.AddEdge(x, d, 1), ShouldBeNil)
+	})
+
+	Convey("Next hops follow the chain deterministically", t, func() {
+		hops, err := graph.NextHops(a, e)
+		So(err, ShouldBeNil)
+		So(hops, ShouldResemble, []cspf.Vertex{b, c, d, e})
+		for i := 0; i < 10; i++ {
+			again, _ := graph.NextHops(a, e)
+			So(again, ShouldResemble, hops)
+		}
+	})
+
+	Convey("Unreachable destinations have no next hops", t, func() {
+		hops, err := graph.NextHops(e, a)
+		So(err, ShouldBeNil)
+		So(hops, ShouldBeEmpty)
+		hops, err = graph.NextHops(a, a)
+		So(err, ShouldBeNil)
+		So(hops, ShouldBeEmpty)
+	})
+
+	Convey("Call NextHops on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.NextHops(a, e)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
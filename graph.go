@@ -14,21 +14,88 @@ import (
 	"errors"
 	"fmt"
 	"math"
-
-	"github.com/PaesslerAG/gval"
+	"math/bits"
+	"reflect"
+	"sort"
 )
 
 var (
 	// ErrDuplicateTagKey is returned by AddEdge method
 	// when one Tag's key was specified more than once.
 	ErrDuplicateTagKey = errors.New("DuplicateTagKey")
+	// ErrDuplicateEdgeID is returned by AddEdgeWithID method
+	// when another edge of the graph has the same ID.
+	ErrDuplicateEdgeID = errors.New("DuplicateEdgeID")
+	// ErrEdgeNotFound is returned whenever a method
+	// refers to an edge that is not part of the graph.
+	ErrEdgeNotFound = errors.New("EdgeNotFound")
+	// ErrEmptyGraph is returned whenever a method
+	// needs a graph with at least one vertex.
+	ErrEmptyGraph = errors.New("EmptyGraph")
+	// ErrEvalTimeout is returned whenever the evaluation
+	// of the expression on an edge takes too long.
+	ErrEvalTimeout = errors.New("EvalTimeout")
+	// ErrInvalidDOT is returned by LoadDOT function
+	// when its input is not a valid DOT graph.
+	ErrInvalidDOT = errors.New("InvalidDOT")
+	// ErrInvalidEncoding is returned by ReadFrom method
+	// when its input is not a valid binary graph.
+	ErrInvalidEncoding = errors.New("InvalidEncoding")
+	// ErrInvalidProbability is returned whenever a tag used
+	// as a probability is not within [0, 1].
+	ErrInvalidProbability = errors.New("InvalidProbability")
+	// ErrNegativeMetric is returned whenever a tag used
+	// as a metric has a negative value.
+	ErrNegativeMetric = errors.New("NegativeMetric")
 	// ErrNilGraph is returned whenever one method
 	// was called on a nil cspf.Graph object
 	ErrNilGraph = errors.New("NilGraph")
+	// ErrNoPath is returned by the queries run with the
+	// WithNoPathError option when the destination vertex
+	// cannot be reached from the source.
+	ErrNoPath = errors.New("NoPath")
+	// ErrNotNumeric is returned whenever a value
+	// that must be a number cannot be converted to one.
+	ErrNotNumeric = errors.New("NotNumeric")
+	// ErrReservedTagKey is returned by ToDOT method when a
+	// tag's key is one of the reserved DOT attributes.
+	ErrReservedTagKey = errors.New("ReservedTagKey")
+	// ErrTagConflict is returned by AddOrUpdateEdge method
+	// when a tag's key is already set to a different value.
+	ErrTagConflict = errors.New("TagConflict")
+	// ErrUnsupportedTagType is returned whenever a tag value
+	// cannot be serialized because of its type.
+	ErrUnsupportedTagType = errors.New("UnsupportedTagType")
+	// ErrVertexNotFound is returned whenever a method
+	// refers to a vertex that is not part of the graph.
+	ErrVertexNotFound = errors.New("VertexNotFound")
 )
 
 const infinity = uint64(math.MaxUint64)
 
+// Unreachable is the distance reported between two
+// vertices when no path connects them.
+const Unreachable = infinity
+
+// addCost sums two costs, saturating at infinity
+// instead of wrapping around on overflow.
+func addCost(a, b uint64) uint64 {
+	if a > infinity-b {
+		return infinity
+	}
+	return a + b
+}
+
+// mulCost multiplies two costs, saturating at infinity
+// instead of wrapping around on overflow.
+func mulCost(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return infinity
+	}
+	return lo
+}
+
 // Tag contains a generic key/value pair that can
 // be attached to cspf.Edge.
 // Key must be unique within a single cspf.Edge.
@@ -63,6 +130,31 @@ type Edge struct {
 	// Tag key is a unique string, whereas
 	// the value can be of any type.
 	Tags map[string]interface{}
+	// Optional identifier of this edge, which tells
+	// parallel edges apart. It is empty by default and it
+	// is not part of the structure of the graph, so it is
+	// ignored when edges or graphs are compared.
+	ID string
+	// Disabled edges stay in the graph, with their cost and
+	// tags, but path searches do not traverse them,
+	// e.g. to model links that are administratively down.
+	// Edges are enabled by default.
+	Disabled bool
+}
+
+// Enabled reports whether the edge can be traversed
+// by shortest-path searches.
+func (e Edge) Enabled() bool {
+	return !e.Disabled
+}
+
+// equal reports whether two edges connect the same vertices
+// with the same cost, the same set of tags and the same state.
+func (e Edge) equal(other Edge) bool {
+	if e.From != other.From || e.To != other.To || e.Cost != other.Cost || e.Disabled != other.Disabled {
+		return false
+	}
+	return equalTags(e.Tags, other.Tags)
 }
 
 // Graph represents a directed graph.
@@ -71,7 +163,21 @@ type Graph struct {
 	// with associated list of edges originating
 	// from every vertex.
 	VertexSet map[Vertex][]Edge
-	eval      gval.Evaluable
+	// RecordMutations enables the log of the changes made
+	// through the methods of the graph, which Mutations
+	// returns. It is off by default. Replacing the content
+	// of the graph, through UnmarshalJSON, ReadFrom or
+	// Normalize, is not recorded.
+	RecordMutations bool
+	// AutoEdgeIDs makes AddEdge and AddOrUpdateEdge assign
+	// a unique ID to every edge they add, in the form "#<n>".
+	// Explicit IDs should not use the same form.
+	AutoEdgeIDs bool
+
+	mutations  []Mutation
+	lastEdgeID int
+	// Default tags of the edges leaving every vertex.
+	vertexTags map[Vertex]map[string]interface{}
 }
 
 func (g *Graph) initGraph() {
@@ -85,6 +191,173 @@ func (g *Graph) initGraph() {
 // If the two vertices do not exist, AddEdge adds them
 // to the graph automatically.
 func (g *Graph) AddEdge(from, to Vertex, cost uint64, tags ...Tag) error {
+	edge, err := newEdge(from, to, cost, tags)
+	if err != nil {
+		return err
+	}
+	g.insertEdge(edge)
+	return nil
+}
+
+// AddEdgeReturning is the same as AddEdge, but it also returns
+// the added edge, e.g. to read the ID assigned by AutoEdgeIDs or
+// to refer to the edge later on without looking it up.
+func (g *Graph) AddEdgeReturning(from, to Vertex, cost uint64, tags ...Tag) (Edge, error) {
+	edge, err := newEdge(from, to, cost, tags)
+	if err != nil {
+		return Edge{}, err
+	}
+	return g.insertEdge(edge), nil
+}
+
+// AddEdgeWithID is the same as AddEdge, but it assigns the
+// given ID to the new edge, so that it can later be targeted
+// by RemoveEdgeByID and UpdateEdgeCostByID.
+// The ID must not be used by any other edge of the graph,
+// otherwise ErrDuplicateEdgeID is returned.
+func (g *Graph) AddEdgeWithID(id string, from, to Vertex, cost uint64, tags ...Tag) error {
+	edge, err := newEdge(from, to, cost, tags)
+	if err != nil {
+		return err
+	}
+	if _, _, found := g.findEdgeByID(id); found {
+		return fmt.Errorf("%w: %s", ErrDuplicateEdgeID, id)
+	}
+	edge.ID = id
+	g.insertEdge(edge)
+	return nil
+}
+
+// insertEdge adds a new edge to the graph, assigning its ID
+// if needed, records the mutation and returns the added edge.
+func (g *Graph) insertEdge(edge Edge) Edge {
+	if edge.ID == "" && g.AutoEdgeIDs {
+		g.lastEdgeID++
+		edge.ID = fmt.Sprintf("#%d", g.lastEdgeID)
+	}
+	g.addEdge(edge)
+	g.record(Mutation{Kind: MutationAddEdge, Edge: edge})
+	return edge
+}
+
+// RemoveEdge removes all the edges that connect one vertex
+// to the other and returns how many were removed.
+// The two vertices are left in the graph.
+func (g *Graph) RemoveEdge(from, to Vertex) int {
+	if g == nil {
+		return 0
+	}
+	edges, ok := g.VertexSet[from]
+	if !ok {
+		return 0
+	}
+	kept := make([]Edge, 0, len(edges))
+	removed := 0
+	for _, edge := range edges {
+		if edge.To != to {
+			kept = append(kept, edge)
+			continue
+		}
+		removed++
+		g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+	}
+	g.VertexSet[from] = kept
+	return removed
+}
+
+// RemoveEdgeByID removes the edge with the given ID.
+// If there is no such edge, ErrEdgeNotFound is returned.
+func (g *Graph) RemoveEdgeByID(id string) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	from, i, found := g.findEdgeByID(id)
+	if !found {
+		return fmt.Errorf("%w: %s", ErrEdgeNotFound, id)
+	}
+	edges := g.VertexSet[from]
+	removed := edges[i]
+	kept := make([]Edge, 0, len(edges)-1)
+	kept = append(kept, edges[:i]...)
+	g.VertexSet[from] = append(kept, edges[i+1:]...)
+	g.record(Mutation{Kind: MutationRemoveEdge, Edge: removed})
+	return nil
+}
+
+// UpdateEdgeCost sets the cost of all the edges that connect
+// one vertex to the other and returns how many were updated.
+func (g *Graph) UpdateEdgeCost(from, to Vertex, cost uint64) int {
+	if g == nil {
+		return 0
+	}
+	updated := 0
+	for i, edge := range g.VertexSet[from] {
+		if edge.To == to {
+			g.setEdgeCost(from, i, cost)
+			updated++
+		}
+	}
+	return updated
+}
+
+// UpdateEdgeCostByID sets the cost of the edge with the given ID.
+// If there is no such edge, ErrEdgeNotFound is returned.
+func (g *Graph) UpdateEdgeCostByID(id string, cost uint64) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	from, i, found := g.findEdgeByID(id)
+	if !found {
+		return fmt.Errorf("%w: %s", ErrEdgeNotFound, id)
+	}
+	g.setEdgeCost(from, i, cost)
+	return nil
+}
+
+// SetEdgeEnabled enables or disables all the edges that connect
+// one vertex to the other and returns how many were changed.
+// Disabled edges keep their cost and tags, so that enabling
+// them again restores the original graph.
+func (g *Graph) SetEdgeEnabled(from, to Vertex, enabled bool) int {
+	if g == nil {
+		return 0
+	}
+	changed := 0
+	for i, edge := range g.VertexSet[from] {
+		if edge.To == to && edge.Enabled() != enabled {
+			g.VertexSet[from][i].Disabled = !enabled
+			g.record(Mutation{Kind: MutationSetEdgeEnabled, Edge: g.VertexSet[from][i], Previous: edge})
+			changed++
+		}
+	}
+	return changed
+}
+
+// setEdgeCost sets the cost of the i-th edge
+// of a vertex and records the mutation.
+func (g *Graph) setEdgeCost(from Vertex, i int, cost uint64) {
+	previous := g.VertexSet[from][i]
+	g.VertexSet[from][i].Cost = cost
+	g.record(Mutation{Kind: MutationUpdateEdgeCost, Edge: g.VertexSet[from][i], Previous: previous})
+}
+
+// findEdgeByID returns the source vertex and the index of the
+// edge with the given ID. The empty ID matches no edge.
+func (g *Graph) findEdgeByID(id string) (Vertex, int, bool) {
+	if id == "" {
+		return Vertex{}, 0, false
+	}
+	for v, edges := range g.VertexSet {
+		for i, edge := range edges {
+			if edge.ID == id {
+				return v, i, true
+			}
+		}
+	}
+	return Vertex{}, 0, false
+}
+
+func newEdge(from, to Vertex, cost uint64, tags []Tag) (Edge, error) {
 	edge := Edge{
 		From: from,
 		To:   to,
@@ -94,87 +367,178 @@ func (g *Graph) AddEdge(from, to Vertex, cost uint64, tags ...Tag) error {
 		edge.Tags = make(map[string]interface{})
 		for _, tag := range tags {
 			if _, ok := edge.Tags[tag.Key]; ok {
-				return fmt.Errorf("%w: %s", ErrDuplicateTagKey, tag.Key)
+				return Edge{}, fmt.Errorf("%w: %s", ErrDuplicateTagKey, tag.Key)
 			}
 			edge.Tags[tag.Key] = tag.Value
 		}
 	}
-	g.addEdge(edge)
+	return edge, nil
+}
+
+// AddOrUpdateEdge merges the given tags into the edge that
+// connects the two vertices with the same cost, if the graph
+// already has one, instead of adding a parallel edge.
+// A tag whose key is already set on the existing edge must have
+// the same value, otherwise ErrTagConflict is returned and the
+// edge is left untouched.
+// If there is no such edge, AddOrUpdateEdge behaves like AddEdge.
+func (g *Graph) AddOrUpdateEdge(from, to Vertex, cost uint64, tags ...Tag) error {
+	edge, err := newEdge(from, to, cost, tags)
+	if err != nil {
+		return err
+	}
+	for i, existing := range g.VertexSet[from] {
+		if existing.To != to || existing.Cost != cost {
+			continue
+		}
+		for key, value := range edge.Tags {
+			if old, ok := existing.Tags[key]; ok && !reflect.DeepEqual(old, value) {
+				return fmt.Errorf("%w: %s", ErrTagConflict, key)
+			}
+		}
+		//The tags of the edge may be shared with the edges
+		//copied into result graphs, so they are not merged
+		//in place
+		existing = existing.withTagsCopy()
+		if len(edge.Tags) != 0 && existing.Tags == nil {
+			existing.Tags = make(map[string]interface{})
+		}
+		for key, value := range edge.Tags {
+			existing.Tags[key] = value
+		}
+		g.recordUpdate(MutationUpdateEdge, g.VertexSet[from][i], existing)
+		g.VertexSet[from][i] = existing
+		return nil
+	}
+	g.insertEdge(edge)
 	return nil
 }
 
+// AddMixedEdge adds a link between two vertices of a graph
+// that mixes directed and undirected links.
+// If bidirectional is false, it behaves like AddEdge and only
+// the edge from a to b is added. Otherwise, an edge with the
+// same cost and tags is added in both directions, so every
+// algorithm can traverse the link either way.
+func (g *Graph) AddMixedEdge(a, b Vertex, cost uint64, bidirectional bool, tags ...Tag) error {
+	err := g.AddEdge(a, b, cost, tags...)
+	if err != nil || !bidirectional {
+		return err
+	}
+	return g.AddEdge(b, a, cost, tags...)
+}
+
 func (g *Graph) addEdge(e Edge) {
-	g.AddNode(e.From)
-	g.AddNode(e.To)
+	g.addNode(e.From)
+	g.addNode(e.To)
 
 	edges := g.VertexSet[e.From]
 	edges = append(edges, e)
 	g.VertexSet[e.From] = edges
 }
 
+// transpose returns a graph with the same vertices
+// and with every edge reversed.
+func (g *Graph) transpose() *Graph {
+	reversed := Graph{}
+	for v, edges := range g.VertexSet {
+		reversed.AddNode(v)
+		for _, edge := range edges {
+			edge.From, edge.To = edge.To, edge.From
+			reversed.addEdge(edge)
+		}
+	}
+	return &reversed
+}
+
 // AddNode adds a new vertex to the graph with no edges.
 // It is preferable to use AddEdge, given that it
 // adds the vertex automatically if missing.
 func (g *Graph) AddNode(v Vertex) {
+	if g.addNode(v) {
+		g.record(Mutation{Kind: MutationAddNode, Vertex: v})
+	}
+}
+
+// addNode adds the vertex if missing and
+// reports whether it was added.
+func (g *Graph) addNode(v Vertex) bool {
 	g.initGraph()
 
 	_, found := g.VertexSet[v]
 	if !found {
 		g.VertexSet[v] = []Edge{}
 	}
+	return !found
+}
+
+// Vertices returns all the vertices of the graph sorted by ID.
+func (g *Graph) Vertices() []Vertex {
+	if g == nil {
+		return nil
+	}
+	vertices := make([]Vertex, 0, len(g.VertexSet))
+	for v := range g.VertexSet {
+		vertices = append(vertices, v)
+	}
+	sortVertices(vertices)
+	return vertices
+}
+
+// sortVertices sorts the vertices by ID.
+func sortVertices(vertices []Vertex) {
+	sort.Slice(vertices, func(i, j int) bool {
+		return vertices[i].ID < vertices[j].ID
+	})
 }
 
 // SPF runs the Dijkstra algorithm to build a result
 // graph only containing the shortest paths from one
 // vertex to another.
 // All shortest paths with equal cost are part of the
-// result graph.
-func (g *Graph) SPF(from, to Vertex) (*Graph, error) {
+// result graph, unless the WithSinglePath option is given.
+func (g *Graph) SPF(from, to Vertex, opts ...Option) (*Graph, error) {
 	if g == nil {
 		return nil, ErrNilGraph
 	}
-	unvisitedSet := make(map[Vertex]bool)
-	distSet := make(map[Vertex]uint64)
-	prevSet := make(map[Vertex][]Edge)
+	return g.spf(from, to, newQuery(opts))
+}
 
-	for v := range g.VertexSet {
-		unvisitedSet[v] = true
-		distSet[v] = infinity
-		prevSet[v] = []Edge{}
-	}
-	distSet[from] = 0
-
-	for len(unvisitedSet) > 0 {
-		setSize := len(unvisitedSet)
-		closestVertex := getSmallestDistanceVertex(unvisitedSet, distSet)
-		delete(unvisitedSet, closestVertex)
-		if setSize == len(unvisitedSet) {
-			//No progress on the visited set, some vertex
-			//of this graph might not be reachable by <from>
-			break
-		}
+// SPFOrError is the same as SPF, but it fails with ErrNoPath
+// when the destination cannot be reached from the source, and
+// with ErrVertexNotFound when either of them is not part of the
+// graph, instead of returning a result graph with no path.
+// It is the same as SPF with the WithNoPathError option.
+func (g *Graph) SPFOrError(from, to Vertex, opts ...Option) (*Graph, error) {
+	return g.SPF(from, to, append(append([]Option{}, opts...), WithNoPathError())...)
+}
 
-		for _, edge := range g.VertexSet[closestVertex] {
-			if stillUnvisited := unvisitedSet[edge.To]; stillUnvisited {
-				satisfied, err := g.edgeSatisfiesConstranints(edge)
-				if err != nil {
-					return nil, err
-				}
-				if satisfied {
-					distFromNeighbor := distSet[closestVertex] + edge.Cost
-					if distFromNeighbor <= distSet[edge.To] {
-						distSet[edge.To] = distFromNeighbor
-						edges := prevSet[edge.To]
-						edges = append(edges, edge)
-						prevSet[edge.To] = edges
-					}
-				}
+func (g *Graph) spf(from, to Vertex, q *query) (*Graph, error) {
+	if q.noPathError {
+		for _, v := range []Vertex{from, to} {
+			if _, ok := g.VertexSet[v]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrVertexNotFound, v.ID)
 			}
 		}
 	}
+	distSet, prevSet, err := g.shortestPaths(from, q)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := distSet[to]; !ok && q.noPathError {
+		return nil, fmt.Errorf("%w: %s->%s", ErrNoPath, from.ID, to.ID)
+	}
+	//A destination of the graph that cannot be reached
+	//gets the shortest-path tree of the source
+	_, found := g.VertexSet[to]
+	return spfGraph(prevSet, found || to == from), nil
+}
 
+// spfGraph builds the result graph of a shortest-path search
+// out of its predecessors, or an empty graph if not reached.
+func spfGraph(prevSet map[Vertex][]Edge, reached bool) *Graph {
 	SPF := Graph{}
-	if _, ok := prevSet[to]; ok || to == from {
+	if reached {
 		for _, edges := range prevSet {
 			for _, edge := range edges {
 				SPF.addEdge(edge)
@@ -182,20 +546,66 @@ func (g *Graph) SPF(from, to Vertex) (*Graph, error) {
 		}
 	}
 
-	return &SPF, nil
+	return &SPF
 }
 
-func getSmallestDistanceVertex(unvisited map[Vertex]bool, distSet map[Vertex]uint64) Vertex {
-	smallestDist := infinity
-	closestVertex := Vertex{}
-	for v := range unvisited {
-		dist := distSet[v]
-		if dist < smallestDist {
-			smallestDist = dist
-			closestVertex = v
+// shortestPaths runs the Dijkstra algorithm from the given vertex
+// and returns the distance of every vertex it reaches, along
+// with the edges that reach each vertex on its shortest paths.
+// State is only allocated for the vertices the search reaches:
+// the vertices that cannot be reached are absent from both maps,
+// i.e. they are at infinity distance.
+// Vertices at the same distance are visited in ID order.
+func (g *Graph) shortestPaths(from Vertex, q *query) (map[Vertex]uint64, map[Vertex][]Edge, error) {
+	distSet := map[Vertex]uint64{from: 0}
+	prevSet := make(map[Vertex][]Edge)
+	visited := make(map[Vertex]bool)
+	queue := vertexQueue{}
+	queue.push(from, 0)
+
+	for queue.Len() > 0 {
+		item := queue.pop()
+		closestVertex := item.vertex
+		if visited[closestVertex] || item.dist != distSet[closestVertex] {
+			//Stale entry of a vertex whose distance
+			//was lowered after it was queued
+			continue
+		}
+		visited[closestVertex] = true
+
+		for i, edge := range g.VertexSet[closestVertex] {
+			if visited[edge.To] {
+				if q.onSkippedEdge != nil {
+					q.onSkippedEdge(edge)
+				}
+				continue
+			}
+			satisfied, err := q.edgeSatisfiesConstranints(edgeRef{from: closestVertex, index: i}, edge)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !satisfied {
+				continue
+			}
+			distFromNeighbor := addCost(distSet[closestVertex], q.cost(edge))
+			if distFromNeighbor == infinity {
+				continue
+			}
+			currentDist, found := distSet[edge.To]
+			switch {
+			case !found || distFromNeighbor < currentDist:
+				//A shorter path makes all the
+				//previous predecessors stale
+				distSet[edge.To] = distFromNeighbor
+				prevSet[edge.To] = []Edge{edge}
+				queue.push(edge.To, distFromNeighbor)
+			case distFromNeighbor == currentDist && !q.singlePath:
+				prevSet[edge.To] = append(prevSet[edge.To], edge)
+			}
 		}
 	}
-	return closestVertex
+
+	return distSet, prevSet, nil
 }
 
 // CSPF runs the Constrained Shortest Path First algorithm
@@ -205,69 +615,161 @@ func getSmallestDistanceVertex(unvisited map[Vertex]bool, distSet map[Vertex]uin
 // do not satisfy the specified expression.
 // The tag key/value pairs and the generic expression are
 // internally evaluated through github.com/PaesslerAG/gval
-// package.
-func (g *Graph) CSPF(from, to Vertex, exp string) (*Graph, error) {
+// package. On top of the gval language, expressions can
+// call between(value, lo, hi) to check that a numeric value
+// falls within an inclusive range, matches(value, pattern)
+// to match a string value against a regular expression, and
+// anyTag(value) to look for a value under any tag key.
+// Edges also inherit the default tags of their source vertex,
+// as set by SetVertexDefaultTag, unless they have their own.
+// CSPF accepts the same options as SPF, as well as
+// WithFunction to extend the language with custom functions.
+func (g *Graph) CSPF(from, to Vertex, exp string, opts ...Option) (*Graph, error) {
 	if g == nil {
 		return nil, ErrNilGraph
 	}
-	eval, err := gval.Full().NewEvaluable(exp)
+	q := newQuery(opts)
+	err := g.compile(q, exp)
 	if err != nil {
 		return nil, err
 	}
-	g.eval = eval
-	return g.SPF(from, to)
+	return g.spf(from, to, q)
 }
 
-func (g *Graph) edgeSatisfiesConstranints(e Edge) (bool, error) {
-	if g.eval == nil {
+// edgeSatisfiesConstranints reports whether the edge, identified
+// by ref, is enabled and satisfies the filters and the expression
+// of the query.
+// The outcome of the expression is cached per edge, so that
+// queries running several searches evaluate it only once.
+func (q *query) edgeSatisfiesConstranints(ref edgeRef, e Edge) (bool, error) {
+	if e.Disabled {
+		return false, nil
+	}
+	for _, filter := range q.filters {
+		if !filter(e) {
+			return false, nil
+		}
+	}
+	if q.eval == nil {
 		return true, nil
 	}
+	if match, ok := q.evalCache[ref]; ok {
+		return match, nil
+	}
 
-	match, err := g.eval.EvalBool(context.Background(), e.Tags)
+	match, err := q.evalBool(q.parameters(e))
 	if err != nil {
 		return false, err
 	}
+	if q.evalCache == nil {
+		q.evalCache = make(map[edgeRef]bool)
+	}
+	q.evalCache[ref] = match
 	return match, nil
 }
 
 // Paths lists all the possible paths of the graph that
-// connect from one vertex to the other.
+// connect from one vertex to the other over enabled edges.
 // Paths are listed through Depth-First Search algorithm.
+// The search uses an explicit stack rather than recursion,
+// so arbitrarily long paths cannot exhaust the goroutine stack.
 func (g *Graph) Paths(from, to Vertex) (paths [][]Edge) {
 	if g == nil {
 		return
 	}
-	//Explore the graph using Depth First Search
-	//starting from the <from> object and listing
-	//all the paths that reach <to>
+	g.walkPaths(from, to, nil, func(path []Edge) {
+		paths = append(paths, path)
+	})
+	return
+}
+
+// PathsWithContext lists the paths of the graph like Paths
+// does, but it stops the enumeration as soon as the context is
+// done, returning the paths found so far along with the error
+// of the context, e.g. context.DeadlineExceeded.
+func (g *Graph) PathsWithContext(ctx context.Context, from, to Vertex) ([][]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	paths := [][]Edge{}
+	done := ctx.Done()
+	err := g.walkPaths(from, to, func(edgeRef, Edge, int, uint64) (bool, error) {
+		select {
+		case <-done:
+			return false, ctx.Err()
+		default:
+			return true, nil
+		}
+	}, func(path []Edge) {
+		paths = append(paths, path)
+	})
+	return paths, err
+}
 
-	visited := make(map[Vertex]bool)
+// walkPaths explores the graph using Depth First Search
+// starting from the <from> vertex and calls found for
+// every simple path that reaches <to>, never traversing
+// disabled edges.
+// If follow is not nil, an edge extends the current path
+// only if follow accepts it, given its reference, the number of hops and
+// the cost of the path the edge would make. An error
+// returned by follow stops the search.
+func (g *Graph) walkPaths(from, to Vertex, follow func(ref edgeRef, edge Edge, hops int, cost uint64) (bool, error), found func(path []Edge)) error {
+	if from == to {
+		found([]Edge{})
+		return nil
+	}
+
+	//Every frame of the stack keeps track of the
+	//next edge to explore out of its vertex and of
+	//the cost of the path that reaches it
+	type frame struct {
+		vertex Vertex
+		next   int
+		cost   uint64
+	}
+	visited := map[Vertex]bool{from: true}
+	stack := []frame{{vertex: from}}
 	path := []Edge{}
 
-	var dfs func(v Vertex, edge *Edge)
-	dfs = func(v Vertex, edge *Edge) {
-		visited[v] = true
-		if edge != nil {
-			path = append(path, *edge)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		edges := g.VertexSet[top.vertex]
+		if top.next == len(edges) {
+			//All the edges of this vertex have been
+			//explored, backtrack to the previous one
+			visited[top.vertex] = false
+			stack = stack[:len(stack)-1]
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			continue
 		}
 
-		if v == to {
-			paths = append(paths, path)
-		} else {
-			for _, edge := range g.VertexSet[v] {
-				if !visited[edge.To] {
-					dfs(edge.To, &edge)
-				}
+		edge := edges[top.next]
+		top.next++
+		if edge.Disabled || visited[edge.To] {
+			continue
+		}
+		cost := addCost(top.cost, edge.Cost)
+		if follow != nil {
+			ok, err := follow(edgeRef{from: top.vertex, index: top.next - 1}, edge, len(path)+1, cost)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
 			}
 		}
-
-		if len(path) > 0 {
-			path = path[1:]
+		path = append(path, edge)
+		if edge.To == to {
+			found(append([]Edge(nil), path...))
+			path = path[:len(path)-1]
+			continue
 		}
-		visited[v] = false
+		visited[edge.To] = true
+		stack = append(stack, frame{vertex: edge.To, cost: cost})
 	}
 
-	dfs(from, nil)
-
-	return
+	return nil
 }
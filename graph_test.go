@@ -1,9 +1,11 @@
 package cspf_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/bigmikes/cspf"
 
@@ -303,3 +305,591 @@ func BenchmarkCSPF(b *testing.B) {
 		_ = spfGraph
 	}
 }
+
+// generateSparseGraph builds n disjoint chains of
+// the given length, so that a search from the head of
+// a chain only explores a tiny region of the graph.
+func generateSparseGraph(n, length int) *cspf.Graph {
+	graph := cspf.Graph{}
+	for i := 0; i < n; i++ {
+		for j := 0; j < length-1; j++ {
+			from := cspf.Vertex{ID: fmt.Sprintf("%d-%d", i, j)}
+			to := cspf.Vertex{ID: fmt.Sprintf("%d-%d", i, j+1)}
+			graph.AddEdge(from, to, 1)
+		}
+	}
+	return &graph
+}
+
+func BenchmarkSPFSparse(b *testing.B) {
+	graph := generateSparseGraph(20000, 10)
+	from := cspf.Vertex{ID: "0-0"}
+	to := cspf.Vertex{ID: "0-9"}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spfGraph, err := graph.SPF(from, to)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = spfGraph
+	}
+}
+
+func BenchmarkCSPFEqual(b *testing.B) {
+	graph, vertices := generateFullyConnectedGraph(100, true)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		spfGraph, err := graph.CSPFEqual(vertices[0], vertices[len(vertices)-1], "key", "value")
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = spfGraph
+	}
+}
+
+func TestPathsOnLongChain(t *testing.T) {
+	const chainLength = 5000
+	graph := cspf.Graph{}
+	vertices := make([]cspf.Vertex, 0, chainLength)
+	for i := 0; i < chainLength; i++ {
+		vertices = append(vertices, cspf.Vertex{ID: fmt.Sprintf("%d", i)})
+	}
+
+	Convey("Populate the graph with a long chain", t, func() {
+		for i := 0; i < chainLength-1; i++ {
+			err := graph.AddEdge(vertices[i], vertices[i+1], 1)
+			So(err, ShouldBeNil)
+		}
+	})
+
+	Convey("List the only path along the chain", t, func() {
+		paths := graph.Paths(vertices[0], vertices[chainLength-1])
+		So(len(paths), ShouldEqual, 1)
+		So(len(paths[0]), ShouldEqual, chainLength-1)
+		So(paths[0][0].From, ShouldResemble, vertices[0])
+		So(paths[0][chainLength-2].To, ShouldResemble, vertices[chainLength-1])
+	})
+}
+
+func TestPathsWithContext(t *testing.T) {
+	//Billions of simple paths connect any two vertices
+	graph, vertices := generateFullyConnectedGraph(14, false)
+	from, to := vertices[0], vertices[len(vertices)-1]
+
+	Convey("A deadline stops the enumeration with partial results", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		paths, err := graph.PathsWithContext(ctx, from, to)
+		So(err, ShouldBeError, context.DeadlineExceeded)
+		So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		So(len(paths), ShouldBeGreaterThan, 0)
+		for _, path := range paths {
+			So(cspf.IsSimplePath(path), ShouldBeTrue)
+			So(path[len(path)-1].To, ShouldResemble, to)
+		}
+	})
+
+	Convey("A cancelled context finds no paths", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		paths, err := graph.PathsWithContext(ctx, from, to)
+		So(err, ShouldBeError, context.Canceled)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("A complete enumeration matches Paths", t, func() {
+		small, smallVertices := generateFullyConnectedGraph(5, false)
+		paths, err := small.PathsWithContext(context.Background(), smallVertices[0], smallVertices[4])
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, small.Paths(smallVertices[0], smallVertices[4]))
+	})
+
+	Convey("Call PathsWithContext on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.PathsWithContext(context.Background(), from, to)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestAddMixedEdge(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with directed and undirected links", t, func() {
+		err := graph.AddMixedEdge(a, b, 1, true)
+		So(err, ShouldBeNil)
+		err = graph.AddMixedEdge(b, c, 1, false)
+		So(err, ShouldBeNil)
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+		So(len(graph.VertexSet[b]), ShouldEqual, 2)
+		So(len(graph.VertexSet[c]), ShouldEqual, 0)
+	})
+
+	Convey("The undirected link can be traversed in reverse", t, func() {
+		spfGraph, err := graph.SPF(b, a)
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(b, a)
+		So(len(paths), ShouldEqual, 1)
+		So(len(paths[0]), ShouldEqual, 1)
+		So(paths[0][0].From, ShouldResemble, b)
+		So(paths[0][0].To, ShouldResemble, a)
+	})
+
+	Convey("The directed link cannot be traversed in reverse", t, func() {
+		spfGraph, err := graph.SPF(c, a)
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(c, a)
+		So(len(paths), ShouldEqual, 0)
+	})
+
+	Convey("Duplicate tag keys are rejected", t, func() {
+		tag := cspf.Tag{Key: "link", Value: "blue"}
+		err := graph.AddMixedEdge(a, c, 1, true, tag, tag)
+		So(errors.Is(err, cspf.ErrDuplicateTagKey), ShouldBeTrue)
+	})
+}
+
+func TestSPFWithSinglePath(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("Run the SPF algorithm recording a single path", t, func() {
+		spfGraph, err := graph.SPF(a, d, cspf.WithSinglePath())
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(len(paths[0]), ShouldEqual, 2)
+		So(paths[0][0].From, ShouldResemble, a)
+		So(paths[0][1].To, ShouldResemble, d)
+		//Every vertex has exactly one predecessor
+		So(len(spfGraph.VertexSet[a])+len(spfGraph.VertexSet[b])+len(spfGraph.VertexSet[c]), ShouldEqual, 3)
+	})
+
+	Convey("Run the CSPF algorithm recording a single path", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `true`, cspf.WithSinglePath())
+		So(err, ShouldBeNil)
+		So(len(cspfGraph.Paths(a, d)), ShouldEqual, 1)
+	})
+}
+
+func TestSPFDiscardsStalePredecessors(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with a costly direct edge", t, func() {
+		So(graph.AddEdge(a, c, 5), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+	})
+
+	Convey("Only the shortest path is part of the result", t, func() {
+		spfGraph, err := graph.SPF(a, c)
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(a, c)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->c")
+	})
+}
+
+func TestSPFSkippedEdgeObserver(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with back-edges", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, a, 1), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1), ShouldBeNil)
+	})
+
+	Convey("Observe the edges leading back to visited vertices", t, func() {
+		skipped := []string{}
+		_, err := graph.SPF(a, c, cspf.WithSkippedEdgeObserver(func(e cspf.Edge) {
+			skipped = append(skipped, cspf.Path{e}.String())
+		}))
+		So(err, ShouldBeNil)
+		So(skipped, ShouldResemble, []string{"b->a", "c->a"})
+	})
+}
+
+func TestAddOrUpdateEdge(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+	tagFast := cspf.Tag{
+		Key:   "speed",
+		Value: "fast",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+
+	graph := cspf.Graph{}
+
+	Convey("Add a new edge", t, func() {
+		err := graph.AddOrUpdateEdge(a, b, 1, tagBlue)
+		So(err, ShouldBeNil)
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+	})
+
+	Convey("Merge the tags into the existing edge", t, func() {
+		spfGraph, err := graph.SPF(a, b)
+		So(err, ShouldBeNil)
+		err = graph.AddOrUpdateEdge(a, b, 1, tagFast, tagBlue)
+		So(err, ShouldBeNil)
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+		So(graph.VertexSet[a][0].Tags, ShouldResemble, map[string]interface{}{
+			"link":  "blue",
+			"speed": "fast",
+		})
+		//Results computed earlier are left untouched
+		So(spfGraph.VertexSet[a][0].Tags, ShouldResemble, map[string]interface{}{
+			"link": "blue",
+		})
+	})
+
+	Convey("A different cost adds a parallel edge", t, func() {
+		err := graph.AddOrUpdateEdge(a, b, 2, tagRed)
+		So(err, ShouldBeNil)
+		So(len(graph.VertexSet[a]), ShouldEqual, 2)
+	})
+
+	Convey("Conflicting tag values are rejected", t, func() {
+		err := graph.AddOrUpdateEdge(a, b, 1, tagRed)
+		So(err, ShouldBeError, fmt.Errorf("%w: %s", cspf.ErrTagConflict, "link"))
+		So(errors.Is(err, cspf.ErrTagConflict), ShouldBeTrue)
+		So(graph.VertexSet[a][0].Tags["link"], ShouldEqual, "blue")
+	})
+
+	Convey("Duplicate tag keys are rejected", t, func() {
+		err := graph.AddOrUpdateEdge(a, b, 1, tagBlue, tagRed)
+		So(errors.Is(err, cspf.ErrDuplicateTagKey), ShouldBeTrue)
+	})
+}
+
+func TestSPFWithUnreachableComponent(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	//The unreachable component includes a vertex
+	//equal to the zero value of cspf.Vertex
+	empty := cspf.Vertex{}
+	x := cspf.Vertex{ID: "x"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with two components", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(empty, x, 1), ShouldBeNil)
+		So(graph.AddEdge(x, empty, 1), ShouldBeNil)
+	})
+
+	Convey("The unreachable component is not part of the result", t, func() {
+		spfGraph, err := graph.SPF(a, b)
+		So(err, ShouldBeNil)
+		So(len(spfGraph.VertexSet), ShouldEqual, 2)
+		_, found := spfGraph.VertexSet[empty]
+		So(found, ShouldBeFalse)
+		_, found = spfGraph.VertexSet[x]
+		So(found, ShouldBeFalse)
+		So(len(spfGraph.Paths(a, b)), ShouldEqual, 1)
+	})
+
+	Convey("The unreachable component cannot be reached", t, func() {
+		spfGraph, err := graph.SPF(a, x)
+		So(err, ShouldBeNil)
+		So(len(spfGraph.Paths(a, x)), ShouldEqual, 0)
+	})
+}
+
+func TestSPFOrError(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	x := cspf.Vertex{ID: "x"}
+	y := cspf.Vertex{ID: "y"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with two components", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(x, y, 1), ShouldBeNil)
+	})
+
+	Convey("A reachable destination has a path", t, func() {
+		spfGraph, err := graph.SPFOrError(a, c)
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, c), ShouldResemble, []string{"a->b->c"})
+		_, err = graph.SPFOrError(a, a)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("A disconnected query fails with ErrNoPath", t, func() {
+		spfGraph, err := graph.SPFOrError(a, y)
+		So(errors.Is(err, cspf.ErrNoPath), ShouldBeTrue)
+		So(errors.Is(err, cspf.ErrVertexNotFound), ShouldBeFalse)
+		So(spfGraph, ShouldBeNil)
+		_, err = graph.CSPF(a, c, `link == "blue"`, cspf.WithNoPathError())
+		So(errors.Is(err, cspf.ErrNoPath), ShouldBeTrue)
+	})
+
+	Convey("An unknown vertex fails with ErrVertexNotFound", t, func() {
+		_, err := graph.SPFOrError(a, cspf.Vertex{ID: "z"})
+		So(errors.Is(err, cspf.ErrVertexNotFound), ShouldBeTrue)
+		So(errors.Is(err, cspf.ErrNoPath), ShouldBeFalse)
+		_, err = graph.SPFOrError(cspf.Vertex{ID: "z"}, a)
+		So(errors.Is(err, cspf.ErrVertexNotFound), ShouldBeTrue)
+	})
+
+	Convey("Without the option, queries stay permissive", t, func() {
+		spfGraph, err := graph.SPF(a, y)
+		So(err, ShouldBeNil)
+		So(spfGraph.Paths(a, y), ShouldBeEmpty)
+	})
+
+	Convey("The options of the caller are left untouched", t, func() {
+		opts := make([]cspf.Option, 1, 2)
+		opts[0] = cspf.WithSinglePath()
+		_, err := graph.SPFOrError(a, y, opts...)
+		So(errors.Is(err, cspf.ErrNoPath), ShouldBeTrue)
+		So(opts[:2][1], ShouldBeNil)
+		_, err = graph.SPF(a, y, opts...)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Call SPFOrError on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFOrError(a, c)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestVertices(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("An empty graph has no vertices", t, func() {
+		So(graph.Vertices(), ShouldBeEmpty)
+	})
+
+	Convey("Populate the graph in non-sorted order", t, func() {
+		So(graph.AddEdge(d, b, 1), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1), ShouldBeNil)
+	})
+
+	Convey("Vertices are sorted by ID", t, func() {
+		So(graph.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c, d})
+	})
+
+	Convey("Call Vertices on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Vertices(), ShouldBeNil)
+	})
+}
+
+func TestEdgeIDs(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+
+	graph := cspf.Graph{}
+
+	Convey("Add two parallel edges with IDs", t, func() {
+		So(graph.AddEdgeWithID("primary", a, b, 1), ShouldBeNil)
+		So(graph.AddEdgeWithID("backup", a, b, 1), ShouldBeNil)
+		err := graph.AddEdgeWithID("primary", a, b, 2)
+		So(errors.Is(err, cspf.ErrDuplicateEdgeID), ShouldBeTrue)
+		So(len(graph.VertexSet[a]), ShouldEqual, 2)
+	})
+
+	Convey("Update the cost of exactly one of them", t, func() {
+		So(graph.UpdateEdgeCostByID("backup", 10), ShouldBeNil)
+		So(graph.VertexSet[a][0].Cost, ShouldEqual, 1)
+		So(graph.VertexSet[a][1].Cost, ShouldEqual, 10)
+	})
+
+	Convey("Remove exactly one of them", t, func() {
+		So(graph.RemoveEdgeByID("primary"), ShouldBeNil)
+		So(graph.VertexSet[a], ShouldResemble, []cspf.Edge{{From: a, To: b, Cost: 10, ID: "backup"}})
+		err := graph.RemoveEdgeByID("primary")
+		So(errors.Is(err, cspf.ErrEdgeNotFound), ShouldBeTrue)
+		err = graph.UpdateEdgeCostByID("", 1)
+		So(errors.Is(err, cspf.ErrEdgeNotFound), ShouldBeTrue)
+	})
+
+	Convey("Update the cost of all the edges between two vertices", t, func() {
+		So(graph.UpdateEdgeCost(a, b, 3), ShouldEqual, 1)
+		So(graph.VertexSet[a][0].Cost, ShouldEqual, 3)
+		So(graph.UpdateEdgeCost(b, a, 3), ShouldEqual, 0)
+	})
+
+	Convey("Assign IDs automatically", t, func() {
+		auto := cspf.Graph{AutoEdgeIDs: true}
+		So(auto.AddEdge(a, b, 1), ShouldBeNil)
+		So(auto.AddEdge(a, b, 1), ShouldBeNil)
+		So(auto.VertexSet[a][0].ID, ShouldEqual, "#1")
+		So(auto.VertexSet[a][1].ID, ShouldEqual, "#2")
+		So(auto.RemoveEdgeByID("#1"), ShouldBeNil)
+		So(auto.VertexSet[a][0].ID, ShouldEqual, "#2")
+	})
+
+	Convey("Call the ID-based methods on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.RemoveEdgeByID("backup"), ShouldBeError, cspf.ErrNilGraph)
+		So(nilGraph.UpdateEdgeCostByID("backup", 1), ShouldBeError, cspf.ErrNilGraph)
+		So(nilGraph.UpdateEdgeCost(a, b, 1), ShouldEqual, 0)
+	})
+}
+
+func TestAddEdgeReturning(t *testing.T) {
+	blue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{AutoEdgeIDs: true}
+
+	Convey("Return the added edges", t, func() {
+		first, err := graph.AddEdgeReturning(a, b, 1, blue)
+		So(err, ShouldBeNil)
+		So(first, ShouldResemble, cspf.Edge{From: a, To: b, Cost: 1, ID: "#1", Tags: map[string]interface{}{"link": "blue"}})
+		So(graph.VertexSet[a], ShouldResemble, []cspf.Edge{first})
+		second, err := graph.AddEdgeReturning(b, c, 2)
+		So(err, ShouldBeNil)
+		So(second.ID, ShouldEqual, "#2")
+	})
+
+	Convey("Remove an edge through the returned value", t, func() {
+		edge, err := graph.AddEdgeReturning(c, a, 3)
+		So(err, ShouldBeNil)
+		So(graph.RemoveEdge(edge.From, edge.To), ShouldEqual, 1)
+		So(graph.VertexSet[c], ShouldBeEmpty)
+		So(graph.RemoveEdgeByID(edge.ID), ShouldBeError)
+	})
+
+	Convey("Return no edge on error", t, func() {
+		edge, err := graph.AddEdgeReturning(a, c, 1, blue, blue)
+		So(errors.Is(err, cspf.ErrDuplicateTagKey), ShouldBeTrue)
+		So(edge, ShouldResemble, cspf.Edge{})
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+	})
+}
+
+// generateDisabledDiamond builds the diamond a->b->d, a->c->d
+// of unit-cost edges where a->b is disabled, so that only the
+// path through c can be traversed.
+func generateDisabledDiamond() *cspf.Graph {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	graph := cspf.Graph{}
+	graph.AddEdge(a, b, 1)
+	graph.AddEdge(b, d, 1)
+	graph.AddEdge(a, c, 1)
+	graph.AddEdge(c, d, 1)
+	graph.SetEdgeEnabled(a, b, false)
+	return &graph
+}
+
+func TestSetEdgeEnabled(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, tagBlue), ShouldBeNil)
+		So(graph.VertexSet[a][0].Enabled(), ShouldBeTrue)
+	})
+
+	Convey("SPF routes around a disabled edge", t, func() {
+		So(graph.SetEdgeEnabled(b, d, false), ShouldEqual, 1)
+		So(graph.SetEdgeEnabled(b, d, false), ShouldEqual, 0)
+		So(graph.VertexSet[b][0].Enabled(), ShouldBeFalse)
+		for _, exp := range []string{"", `link == "blue"`} {
+			var result *cspf.Graph
+			var err error
+			if exp == "" {
+				result, err = graph.SPF(a, d)
+			} else {
+				result, err = graph.CSPF(a, d, exp)
+			}
+			So(err, ShouldBeNil)
+			paths := result.Paths(a, d)
+			So(len(paths), ShouldEqual, 1)
+			So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		}
+	})
+
+	Convey("Enabling the edge again restores the original path", t, func() {
+		So(graph.SetEdgeEnabled(b, d, true), ShouldEqual, 1)
+		So(graph.VertexSet[b][0], ShouldResemble, cspf.Edge{
+			From: b, To: d, Cost: 1, Tags: map[string]interface{}{"link": "blue"},
+		})
+		spf, err := graph.SPF(a, d)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d")
+	})
+
+	Convey("Paths do not traverse disabled edges", t, func() {
+		diamond := generateDisabledDiamond()
+		So(diamond.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Call SetEdgeEnabled on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.SetEdgeEnabled(a, b, false), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,170 @@
+package cspf
+
+// PathIter lazily enumerates the simple paths between two
+// vertices in ascending cost order, as returned by PathIterator.
+type PathIter struct {
+	graph    *Graph
+	from, to Vertex
+	// Paths yielded so far, in order.
+	found []Path
+	// Candidates for the next path.
+	candidates []Path
+	done       bool
+}
+
+// PathIterator returns an iterator over the simple paths that
+// connect from one vertex to the other, in ascending cost order.
+// Paths are computed on demand with the Yen algorithm: each call
+// to Next runs a bounded number of shortest-path searches, so the
+// first paths are available without enumerating all of them.
+// The first path is the shortest path built by following, back
+// from the destination, the smallest edge among the ones that
+// reach every vertex on a shortest path. Every following
+// path is the cheapest of the candidates found so far, ties
+// broken by String representation, so the sequence is
+// deterministic, but paths of equal cost are not necessarily
+// yielded in String order: a candidate found later can sort
+// before one already yielded.
+// The iterator works on the graph as it is when Next is called:
+// the graph must not be modified during the iteration.
+func (g *Graph) PathIterator(from, to Vertex) (*PathIter, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	return &PathIter{graph: g, from: from, to: to}, nil
+}
+
+// Next returns the next path, or false if there are no more paths.
+func (it *PathIter) Next() ([]Edge, bool) {
+	if it.done {
+		return nil, false
+	}
+	if len(it.found) == 0 {
+		if it.from == it.to {
+			it.done = true
+			return []Edge{}, true
+		}
+		path, ok := it.graph.shortestPath(it.from, it.to, newQuery(nil))
+		if !ok {
+			it.done = true
+			return nil, false
+		}
+		it.found = append(it.found, path)
+		return path, true
+	}
+
+	it.addCandidates(it.found[len(it.found)-1])
+	if len(it.candidates) == 0 {
+		it.done = true
+		return nil, false
+	}
+	best := 0
+	for i, candidate := range it.candidates {
+		if lessPath(candidate, it.candidates[best]) {
+			best = i
+		}
+	}
+	path := it.candidates[best]
+	it.candidates = append(it.candidates[:best], it.candidates[best+1:]...)
+	it.found = append(it.found, path)
+	return path, true
+}
+
+// addCandidates adds the deviations of the given path to the
+// candidates: for every vertex of the path, the shortest path
+// that shares the path up to that vertex, and then leaves it
+// through an edge that no path found so far with the same
+// prefix has taken.
+func (it *PathIter) addCandidates(last Path) {
+	for i := range last {
+		root := last[:i]
+		spur := last[i].From
+
+		removedVertices := make(map[Vertex]bool, i)
+		for _, edge := range root {
+			removedVertices[edge.From] = true
+		}
+		var removedEdges []Edge
+		for _, path := range it.found {
+			if len(path) > i && samePath(path[:i], root) {
+				removedEdges = append(removedEdges, path[i])
+			}
+		}
+
+		q := newQuery(nil)
+		q.filters = append(q.filters, func(e Edge) bool {
+			return !removedVertices[e.To] && !containsEdge(removedEdges, e)
+		})
+		spurPath, ok := it.graph.shortestPath(spur, it.to, q)
+		if !ok {
+			continue
+		}
+		candidate := make(Path, 0, len(root)+len(spurPath))
+		candidate = append(candidate, root...)
+		candidate = append(candidate, spurPath...)
+		if !it.known(candidate) {
+			it.candidates = append(it.candidates, candidate)
+		}
+	}
+}
+
+// known reports whether the path was already yielded
+// or is already one of the candidates.
+func (it *PathIter) known(path Path) bool {
+	for _, other := range it.found {
+		if samePath(path, other) {
+			return true
+		}
+	}
+	for _, other := range it.candidates {
+		if samePath(path, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortestPath returns one shortest path between two vertices,
+// or false if the destination cannot be reached. Among equal-cost
+// paths, it follows the smallest predecessor edge of every vertex.
+func (g *Graph) shortestPath(from, to Vertex, q *query) (Path, bool) {
+	distSet, prevSet, err := g.shortestPaths(from, q)
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := distSet[to]; !ok || from == to {
+		return nil, false
+	}
+	path := Path{}
+	for v := to; v != from; {
+		edge := sortedEdges(prevSet[v])[0]
+		path = append(path, edge)
+		v = edge.From
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// samePath reports whether two paths are made of the same edges.
+func samePath(a, b []Edge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lessPath reports whether path a ranks before path b,
+// by cost and then by String representation.
+func lessPath(a, b Path) bool {
+	if a.Cost() != b.Cost() {
+		return a.Cost() < b.Cost()
+	}
+	return a.String() < b.String()
+}
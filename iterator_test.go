@@ -0,0 +1,66 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPathIterator(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(a, d, 5), ShouldBeNil)
+	})
+
+	Convey("Pull the first two paths in cost order", t, func() {
+		it, err := graph.PathIterator(a, d)
+		So(err, ShouldBeNil)
+		first, ok := it.Next()
+		So(ok, ShouldBeTrue)
+		So(cspf.Path(first).String(), ShouldEqual, "a->b->d")
+		So(cspf.Path(first).Cost(), ShouldEqual, 2)
+		second, ok := it.Next()
+		So(ok, ShouldBeTrue)
+		So(cspf.Path(second).Cost(), ShouldBeGreaterThanOrEqualTo, cspf.Path(first).Cost())
+		So(cspf.Path(second).String(), ShouldEqual, "a->b->c->d")
+	})
+
+	Convey("Drain the iterator", t, func() {
+		it, err := graph.PathIterator(a, d)
+		So(err, ShouldBeNil)
+		var paths []string
+		for path, ok := it.Next(); ok; path, ok = it.Next() {
+			paths = append(paths, cspf.Path(path).String())
+		}
+		//Equal-cost paths are ordered by their representation
+		So(paths, ShouldResemble, []string{"a->b->d", "a->b->c->d", "a->c->d", "a->d"})
+		_, ok := it.Next()
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("An unreachable destination has no paths", t, func() {
+		it, err := graph.PathIterator(d, a)
+		So(err, ShouldBeNil)
+		_, ok := it.Next()
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Call PathIterator on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.PathIterator(a, d)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
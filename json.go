@@ -0,0 +1,172 @@
+package cspf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonGraph is the JSON representation of a graph.
+type jsonGraph struct {
+	Vertices   []string         `json:"vertices"`
+	Edges      []jsonEdge       `json:"edges"`
+	VertexTags []jsonVertexTags `json:"vertexTags,omitempty"`
+}
+
+// jsonVertexTags is the JSON representation
+// of the default tags of a vertex.
+type jsonVertexTags struct {
+	Vertex string    `json:"vertex"`
+	Tags   []jsonTag `json:"tags"`
+}
+
+// jsonEdge is the JSON representation of an edge.
+type jsonEdge struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Cost     uint64    `json:"cost"`
+	ID       string    `json:"id,omitempty"`
+	Disabled bool      `json:"disabled,omitempty"`
+	Tags     []jsonTag `json:"tags,omitempty"`
+}
+
+// jsonTag is the JSON representation of a tag: the value is
+// in its canonical text encoding, along with the name of its
+// type, so that no precision nor type is lost.
+type jsonTag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// MarshalJSON encodes the graph as a JSON object listing the
+// IDs of its vertices, sorted, its edges, by source vertex
+// and then in insertion order, with their costs, IDs, states
+// and tags sorted by key, and the default tags of its vertices.
+// Every tag is written as its key, the name of its type and its
+// value as a string, e.g. {"key":"delay","type":"int","value":"5"},
+// so that UnmarshalJSON reads back nil, bools, strings and all
+// the int, uint and float types with the same type and value.
+// Values of any other type make MarshalJSON fail with
+// ErrUnsupportedTagType.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	if g == nil {
+		return []byte("null"), nil
+	}
+	encoded := jsonGraph{Vertices: []string{}, Edges: []jsonEdge{}}
+	vertices := g.Vertices()
+	for _, v := range vertices {
+		encoded.Vertices = append(encoded.Vertices, v.ID)
+	}
+	for _, v := range vertices {
+		for _, edge := range g.VertexSet[v] {
+			e := jsonEdge{
+				From:     edge.From.ID,
+				To:       edge.To.ID,
+				Cost:     edge.Cost,
+				ID:       edge.ID,
+				Disabled: edge.Disabled,
+			}
+			tags, err := encodeJSONTags(edge.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("%w of edge %s->%s", err, edge.From.ID, edge.To.ID)
+			}
+			e.Tags = tags
+			encoded.Edges = append(encoded.Edges, e)
+		}
+	}
+	for _, v := range vertices {
+		if len(g.vertexTags[v]) == 0 {
+			continue
+		}
+		tags, err := encodeJSONTags(g.vertexTags[v])
+		if err != nil {
+			return nil, fmt.Errorf("%w of vertex %s", err, v.ID)
+		}
+		encoded.VertexTags = append(encoded.VertexTags, jsonVertexTags{Vertex: v.ID, Tags: tags})
+	}
+	return json.Marshal(encoded)
+}
+
+// encodeJSONTags encodes a set of tags sorted by key.
+func encodeJSONTags(tags map[string]interface{}) ([]jsonTag, error) {
+	var encoded []jsonTag
+	for _, key := range sortedKeys(tags) {
+		t, text, err := formatTag(tags[key])
+		if err != nil {
+			return nil, fmt.Errorf("%w: tag %s", err, key)
+		}
+		encoded = append(encoded, jsonTag{Key: key, Type: t.String(), Value: text})
+	}
+	return encoded, nil
+}
+
+// decodeJSONTags decodes a set of tags encoded by encodeJSONTags.
+func decodeJSONTags(encoded []jsonTag) (map[string]interface{}, error) {
+	var tags map[string]interface{}
+	for _, tag := range encoded {
+		if tags == nil {
+			tags = make(map[string]interface{}, len(encoded))
+		}
+		if _, ok := tags[tag.Key]; ok {
+			return nil, fmt.Errorf("duplicate tag %s", tag.Key)
+		}
+		t, ok := parseTagType(tag.Type)
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q of tag %s", tag.Type, tag.Key)
+		}
+		value, err := parseTag(t, tag.Value)
+		if err != nil {
+			return nil, fmt.Errorf("tag %s: %v", tag.Key, err)
+		}
+		tags[tag.Key] = value
+	}
+	return tags, nil
+}
+
+// UnmarshalJSON replaces the content of the graph, including
+// the default tags of its vertices, with a graph encoded by
+// MarshalJSON. Vertices the edges refer to are added even if
+// they are not listed.
+// Input that is not a valid JSON graph makes UnmarshalJSON fail
+// with ErrInvalidEncoding, leaving the graph unchanged.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	var encoded jsonGraph
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+
+	read := Graph{}
+	for _, id := range encoded.Vertices {
+		read.addNode(Vertex{ID: id})
+	}
+	for _, e := range encoded.Edges {
+		edge := Edge{
+			From:     Vertex{ID: e.From},
+			To:       Vertex{ID: e.To},
+			Cost:     e.Cost,
+			ID:       e.ID,
+			Disabled: e.Disabled,
+		}
+		tags, err := decodeJSONTags(e.Tags)
+		if err != nil {
+			return fmt.Errorf("%w: %v of edge %s->%s", ErrInvalidEncoding, err, e.From, e.To)
+		}
+		edge.Tags = tags
+		read.addEdge(edge)
+	}
+	for _, vt := range encoded.VertexTags {
+		tags, err := decodeJSONTags(vt.Tags)
+		if err != nil {
+			return fmt.Errorf("%w: %v of vertex %s", ErrInvalidEncoding, err, vt.Vertex)
+		}
+		for key, value := range tags {
+			read.SetVertexDefaultTag(Vertex{ID: vt.Vertex}, key, value)
+		}
+	}
+	g.VertexSet = read.VertexSet
+	g.vertexTags = read.vertexTags
+	return nil
+}
@@ -0,0 +1,178 @@
+package cspf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdgeWithID("ab", a, b, 1, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(b, c, math.MaxUint64), ShouldBeNil)
+		So(graph.SetEdgeEnabled(b, c, false), ShouldEqual, 1)
+		graph.AddNode(cspf.Vertex{ID: "d"})
+	})
+
+	Convey("The encoding is readable and deterministic", t, func() {
+		encoded, err := json.Marshal(&graph)
+		So(err, ShouldBeNil)
+		So(string(encoded), ShouldEqual, `{"vertices":["a","b","c","d"],"edges":[`+
+			`{"from":"a","to":"b","cost":1,"id":"ab","tags":[{"key":"link","type":"string","value":"red"}]},`+
+			`{"from":"b","to":"c","cost":18446744073709551615,"disabled":true}]}`)
+	})
+
+	Convey("Read back the graph", t, func() {
+		encoded, err := json.Marshal(&graph)
+		So(err, ShouldBeNil)
+		read := cspf.Graph{}
+		So(json.Unmarshal(encoded, &read), ShouldBeNil)
+		So(read.Equal(&graph), ShouldBeTrue)
+		So(read.VertexSet[a][0].ID, ShouldEqual, "ab")
+	})
+
+	Convey("Invalid input leaves the graph unchanged", t, func() {
+		for _, input := range []string{
+			`{"vertices":3}`,
+			`{"edges":[{"from":"a","to":"b","tags":[{"key":"k","type":"complex128","value":"1"}]}]}`,
+			`{"edges":[{"from":"a","to":"b","tags":[{"key":"k","type":"int8","value":"300"}]}]}`,
+			`{"edges":[{"from":"a","to":"b","tags":[{"key":"k","type":"bool","value":"yes"}]}]}`,
+			`{"edges":[{"from":"a","to":"b","tags":[{"key":"k","type":"int","value":"1"},{"key":"k","type":"int","value":"2"}]}]}`,
+		} {
+			read := cspf.Graph{}
+			So(read.AddEdge(a, c, 1), ShouldBeNil)
+			err := json.Unmarshal([]byte(input), &read)
+			So(errors.Is(err, cspf.ErrInvalidEncoding), ShouldBeTrue)
+			So(len(read.VertexSet), ShouldEqual, 2)
+		}
+	})
+
+	Convey("Unsupported tag types cannot be encoded", t, func() {
+		unsupported := cspf.Graph{}
+		So(unsupported.AddEdge(a, b, 1, cspf.Tag{Key: "k", Value: []int{1}}), ShouldBeNil)
+		_, err := json.Marshal(&unsupported)
+		So(errors.Is(err, cspf.ErrUnsupportedTagType), ShouldBeTrue)
+	})
+
+	Convey("Encode a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		encoded, err := json.Marshal(nilGraph)
+		So(err, ShouldBeNil)
+		So(string(encoded), ShouldEqual, "null")
+		So(nilGraph.UnmarshalJSON([]byte("{}")), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestTagTypeFidelity(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	tags := map[string]interface{}{
+		"nil":     nil,
+		"bool":    true,
+		"string":  "text",
+		"int":     -1,
+		"int8":    int8(math.MinInt8),
+		"int16":   int16(math.MaxInt16),
+		"int32":   int32(math.MinInt32),
+		"int64":   int64(math.MaxInt64),
+		"uint":    uint(1),
+		"uint8":   uint8(math.MaxUint8),
+		"uint16":  uint16(math.MaxUint16),
+		"uint32":  uint32(math.MaxUint32),
+		"uint64":  uint64(math.MaxUint64),
+		"float32": float32(0.1),
+		"float64": math.SmallestNonzeroFloat64,
+		"inf":     math.Inf(-1),
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with a tag of every type", t, func() {
+		var list []cspf.Tag
+		for key, value := range tags {
+			list = append(list, cspf.Tag{Key: key, Value: value})
+		}
+		So(graph.AddEdge(a, b, 1, list...), ShouldBeNil)
+	})
+
+	Convey("JSON and binary read back the same values and types", t, func() {
+		encoded, err := json.Marshal(&graph)
+		So(err, ShouldBeNil)
+		fromJSON := cspf.Graph{}
+		So(json.Unmarshal(encoded, &fromJSON), ShouldBeNil)
+
+		var buf bytes.Buffer
+		_, err = graph.WriteTo(&buf)
+		So(err, ShouldBeNil)
+		fromBinary := cspf.Graph{}
+		_, err = fromBinary.ReadFrom(&buf)
+		So(err, ShouldBeNil)
+
+		for _, read := range []*cspf.Graph{&fromJSON, &fromBinary} {
+			readTags := read.VertexSet[a][0].Tags
+			So(len(readTags), ShouldEqual, len(tags))
+			for key, value := range tags {
+				So(reflect.TypeOf(readTags[key]), ShouldEqual, reflect.TypeOf(value))
+				So(readTags[key], ShouldEqual, value)
+			}
+		}
+		So(fromJSON.Equal(&fromBinary), ShouldBeTrue)
+	})
+
+	Convey("DOT reads back its default types", t, func() {
+		var buf bytes.Buffer
+		So(graph.ToDOT(&buf), ShouldBeNil)
+		fromDOT, err := cspf.LoadDOT(&buf)
+		So(err, ShouldBeNil)
+		readTags := fromDOT.VertexSet[a][0].Tags
+		for _, key := range []string{"bool", "string", "int", "float64"} {
+			So(reflect.TypeOf(readTags[key]), ShouldEqual, reflect.TypeOf(tags[key]))
+			So(readTags[key], ShouldEqual, tags[key])
+		}
+		So(readTags["int8"], ShouldEqual, math.MinInt8)
+		So(readTags["float32"], ShouldEqual, 0.1)
+	})
+}
+
+func BenchmarkJSONMarshal(b *testing.B) {
+	graph := generateTaggedGraph(10000)
+	var encoded []byte
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		encoded, err = json.Marshal(graph)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(encoded)), "bytes")
+}
+
+func BenchmarkJSONUnmarshal(b *testing.B) {
+	encoded, err := json.Marshal(generateTaggedGraph(10000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		graph := cspf.Graph{}
+		if err := json.Unmarshal(encoded, &graph); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package cspf
+
+import (
+	"fmt"
+	"math"
+)
+
+// Special metric names that an Objective can refer to,
+// instead of the name of an edge tag.
+const (
+	// CostMetric refers to the cost of the edges.
+	CostMetric = "cost"
+	// HopsMetric counts every edge as 1.
+	HopsMetric = "hops"
+)
+
+// Direction states whether an objective must be
+// minimized or maximized.
+type Direction int
+
+const (
+	// Minimize prefers smaller values.
+	Minimize Direction = iota
+	// Maximize prefers larger values.
+	Maximize
+)
+
+// Aggregation states how the per-edge values of a
+// metric combine into the value of a whole path.
+type Aggregation int
+
+const (
+	// AggregateSum sums the values of all the edges.
+	AggregateSum Aggregation = iota
+	// AggregateMin takes the smallest value of all the edges.
+	AggregateMin
+	// AggregateMax takes the largest value of all the edges.
+	AggregateMax
+)
+
+// Objective is one of the ranked criteria used by
+// SPFLexicographic to compare paths.
+type Objective struct {
+	// Metric is the key of the numeric tag the objective
+	// is computed from, or one of CostMetric and HopsMetric.
+	Metric string
+	// Direction of the optimization.
+	Direction Direction
+	// Aggregation of the values along the path.
+	Aggregation Aggregation
+	// optional counts the edges lacking the tag as 0.
+	optional bool
+}
+
+// start returns the value of the objective on an empty path.
+func (o Objective) start() float64 {
+	switch o.Aggregation {
+	case AggregateMin:
+		return math.Inf(1)
+	case AggregateMax:
+		return math.Inf(-1)
+	}
+	return 0
+}
+
+// value returns the value of the objective's metric on an edge.
+func (o Objective) value(e Edge) (float64, error) {
+	switch o.Metric {
+	case CostMetric:
+		return float64(e.Cost), nil
+	case HopsMetric:
+		return 1, nil
+	}
+	tag, found := e.Tags[o.Metric]
+	if !found && o.optional {
+		return 0, nil
+	}
+	value, ok := toFloat64(tag)
+	if !ok {
+		return 0, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, o.Metric, e.From.ID, e.To.ID)
+	}
+	return value, nil
+}
+
+// extend combines the value of a path with the value of one more edge.
+func (o Objective) extend(path, edge float64) float64 {
+	switch o.Aggregation {
+	case AggregateMin:
+		return math.Min(path, edge)
+	case AggregateMax:
+		return math.Max(path, edge)
+	}
+	return path + edge
+}
+
+// better reports whether value a is preferable to value b.
+func (o Objective) better(a, b float64) bool {
+	if o.Direction == Maximize {
+		return a > b
+	}
+	return a < b
+}
+
+// lexicographicLabel holds the value of every
+// objective on the best path found to a vertex.
+type lexicographicLabel []float64
+
+// betterLabel reports whether label a ranks before label b
+// according to the given objectives.
+func betterLabel(objectives []Objective, a, b lexicographicLabel) bool {
+	for i, o := range objectives {
+		if o.better(a[i], b[i]) {
+			return true
+		}
+		if o.better(b[i], a[i]) {
+			return false
+		}
+	}
+	return false
+}
+
+// SPFLexicographic builds a result graph containing one best
+// path from one vertex to every other, where paths are ranked
+// by an ordered list of objectives: a path is better than another
+// if it is better on the first objective, or if it ties on it and
+// is better on the second one, and so on.
+// With no objectives, paths are ranked by their cost.
+//
+// The search is a Dijkstra algorithm on the vector of objective
+// values, so only a first objective that minimizes the sum of
+// non-negative values, like CostMetric, is guaranteed to be optimal.
+// The following objectives act as tie-breakers among the paths
+// that the search explores, and they are not guaranteed to find
+// the globally best path among all the ties.
+// Remaining ties are broken deterministically, by vertex ID
+// and by edge insertion order. Disabled edges are never traversed.
+//
+// Edges lacking a numeric tag required by an objective
+// make the query fail with ErrNotNumeric.
+func (g *Graph) SPFLexicographic(from, to Vertex, objectives []Objective) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	if len(objectives) == 0 {
+		objectives = []Objective{{Metric: CostMetric}}
+	}
+
+	labels := make(map[Vertex]lexicographicLabel)
+	prevSet := make(map[Vertex]Edge)
+	unvisitedSet := make(map[Vertex]bool)
+	for v := range g.VertexSet {
+		unvisitedSet[v] = true
+	}
+	startLabel := make(lexicographicLabel, len(objectives))
+	for i, o := range objectives {
+		startLabel[i] = o.start()
+	}
+	labels[from] = startLabel
+
+	for len(unvisitedSet) > 0 {
+		//Select the unvisited vertex with the best label,
+		//breaking ties by ID to be deterministic
+		var closest Vertex
+		found := false
+		for v := range unvisitedSet {
+			label, ok := labels[v]
+			if !ok {
+				continue
+			}
+			if !found || betterLabel(objectives, label, labels[closest]) ||
+				(!betterLabel(objectives, labels[closest], label) && v.ID < closest.ID) {
+				closest = v
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+		delete(unvisitedSet, closest)
+
+		for _, edge := range g.VertexSet[closest] {
+			if edge.Disabled || !unvisitedSet[edge.To] {
+				continue
+			}
+			label := make(lexicographicLabel, len(objectives))
+			for i, o := range objectives {
+				value, err := o.value(edge)
+				if err != nil {
+					return nil, err
+				}
+				label[i] = o.extend(labels[closest][i], value)
+			}
+			current, ok := labels[edge.To]
+			if !ok || betterLabel(objectives, label, current) {
+				labels[edge.To] = label
+				prevSet[edge.To] = edge
+			}
+		}
+	}
+
+	result := Graph{}
+	if _, ok := g.VertexSet[to]; ok || to == from {
+		for _, edge := range prevSet {
+			result.addEdge(edge)
+		}
+	}
+	return &result, nil
+}
+
+// SPFAdminWeighted builds a result graph containing one shortest
+// path from one vertex to every other, where the administrative
+// weight of the paths breaks the ties among those of equal cost:
+// the path with the smallest sum of admin weights is preferred.
+// The admin weight of an edge is the value of its numeric tag
+// adminTag, or zero if the edge does not carry the tag.
+// Remaining ties are broken as SPFLexicographic does.
+// Admin weights must be non-negative numbers, otherwise the
+// query fails with ErrNotNumeric or ErrNegativeMetric.
+// Disabled edges are never traversed, so their admin
+// weights are not checked.
+func (g *Graph) SPFAdminWeighted(from, to Vertex, adminTag string) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			tag, found := edge.Tags[adminTag]
+			if !found || edge.Disabled {
+				continue
+			}
+			value, ok := toFloat64(tag)
+			if !ok {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, adminTag, edge.From.ID, edge.To.ID)
+			}
+			if value < 0 {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNegativeMetric, adminTag, edge.From.ID, edge.To.ID)
+			}
+		}
+	}
+	//Both objectives minimize sums of non-negative values,
+	//so the search finds the best path for the pair of them
+	return g.SPFLexicographic(from, to, []Objective{
+		{Metric: CostMetric},
+		{Metric: adminTag, optional: true},
+	})
+}
@@ -0,0 +1,142 @@
+package cspf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSPFLexicographic(t *testing.T) {
+	reliability := func(value float64) cspf.Tag {
+		return cspf.Tag{Key: "reliability", Value: value}
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, reliability(0.9)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, reliability(0.9)), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, reliability(0.99)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, reliability(0.95)), ShouldBeNil)
+		So(graph.AddEdge(a, e, 1, reliability(0.999)), ShouldBeNil)
+		So(graph.AddEdge(e, d, 2, reliability(0.999)), ShouldBeNil)
+	})
+
+	Convey("Minimize cost, then maximize the weakest reliability", t, func() {
+		spfGraph, err := graph.SPFLexicographic(a, d, []cspf.Objective{
+			{Metric: cspf.CostMetric},
+			{Metric: "reliability", Direction: cspf.Maximize, Aggregation: cspf.AggregateMin},
+		})
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Minimize hops, then minimize cost", t, func() {
+		spfGraph, err := graph.SPFLexicographic(a, d, []cspf.Objective{
+			{Metric: cspf.HopsMetric},
+			{Metric: cspf.CostMetric},
+		})
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+	})
+
+	Convey("Without objectives, ties are broken deterministically", t, func() {
+		for i := 0; i < 10; i++ {
+			spfGraph, err := graph.SPFLexicographic(a, d, nil)
+			So(err, ShouldBeNil)
+			So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+		}
+	})
+
+	Convey("A missing tag is reported as an error", t, func() {
+		_, err := graph.SPFLexicographic(a, d, []cspf.Objective{{Metric: "latency"}})
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+	})
+
+	Convey("Disabled edges are never traversed", t, func() {
+		spfGraph, err := generateDisabledDiamond().SPFLexicographic(a, d, nil)
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Call SPFLexicographic on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFLexicographic(a, d, nil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFAdminWeighted(t *testing.T) {
+	admin := func(value interface{}) cspf.Tag {
+		return cspf.Tag{Key: "admin", Value: value}
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, admin(10)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, admin(1)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, e, 1), ShouldBeNil)
+		So(graph.AddEdge(e, d, 2), ShouldBeNil)
+	})
+
+	Convey("Without admin weights, the tie is broken by ID", t, func() {
+		spfGraph, err := graph.SPFAdminWeighted(a, d, "unknown")
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+	})
+
+	Convey("The admin weight breaks the cost tie", t, func() {
+		spfGraph, err := graph.SPFAdminWeighted(a, d, "admin")
+		So(err, ShouldBeNil)
+		//a->e->d has no admin weight, but it costs more
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Invalid admin weights are reported as errors", t, func() {
+		invalid := cspf.Graph{}
+		So(invalid.AddEdge(a, b, 1, admin("high")), ShouldBeNil)
+		_, err := invalid.SPFAdminWeighted(a, b, "admin")
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+
+		negative := cspf.Graph{}
+		So(negative.AddEdge(a, b, 1, admin(-1)), ShouldBeNil)
+		_, err = negative.SPFAdminWeighted(a, b, "admin")
+		So(errors.Is(err, cspf.ErrNegativeMetric), ShouldBeTrue)
+	})
+
+	Convey("Disabled edges are never traversed", t, func() {
+		diamond := generateDisabledDiamond()
+		spfGraph, err := diamond.SPFAdminWeighted(a, d, "admin")
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		//Nor are their admin weights checked
+		So(diamond.AddEdge(a, d, 5, admin("high")), ShouldBeNil)
+		So(diamond.SetEdgeEnabled(a, d, false), ShouldEqual, 1)
+		_, err = diamond.SPFAdminWeighted(a, d, "admin")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Call SPFAdminWeighted on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFAdminWeighted(a, d, "admin")
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
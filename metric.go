@@ -0,0 +1,177 @@
+package cspf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SPFBlended runs the SPF algorithm on a blend of two metrics:
+// the effective weight of every edge is alpha times its cost
+// plus beta times the value of its numeric tag metricTag,
+// rounded to the nearest integer. The weighted sum saturates at
+// the largest uint64 value instead of overflowing, and such
+// edges are treated as unreachable.
+// The edges of the result graph keep their own cost.
+// Every enabled edge must have a finite non-negative numeric value
+// for the tag: NaN and infinite values make the query fail with
+// ErrNotNumeric, and negative ones with ErrNegativeMetric.
+// Disabled edges are never traversed, so their value is not
+// checked.
+func (g *Graph) SPFBlended(from, to Vertex, alpha uint64, metricTag string, beta uint64) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.Disabled {
+				continue
+			}
+			value, ok := toFloat64(edge.Tags[metricTag])
+			if !ok || math.IsNaN(value) || math.IsInf(value, 1) {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, metricTag, edge.From.ID, edge.To.ID)
+			}
+			if value < 0 {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNegativeMetric, metricTag, edge.From.ID, edge.To.ID)
+			}
+		}
+	}
+
+	q := newQuery(nil)
+	q.costFunc = func(e Edge) uint64 {
+		//All the values were validated above
+		value, _ := toFloat64(e.Tags[metricTag])
+		return addCost(mulCost(alpha, e.Cost), scaleMetric(beta, value))
+	}
+	return g.spf(from, to, q)
+}
+
+// SPFWeightedMetrics runs the SPF algorithm on a composite
+// metric: the effective weight of every edge is the sum of the
+// values of its numeric tags named by weights, each multiplied by
+// its weight, e.g. {"delay": 1, "loss": 1000}. Edges lacking one of
+// the tags count it as zero, and the edges of the result graph
+// keep their own cost.
+// The sum is computed on float64 values and rounded to the nearest
+// integer, so that fractional contributions are only meaningful in
+// aggregate: scale the weights up to keep more precision. The sum
+// saturates at the largest uint64 value, and such edges are
+// treated as unreachable.
+// Weights and tag values must be finite non-negative numbers: NaN
+// and infinite values make the query fail with ErrNotNumeric, and
+// negative ones with ErrNegativeMetric.
+func (g *Graph) SPFWeightedMetrics(from, to Vertex, weights map[string]float64) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	keys := make([]string, 0, len(weights))
+	for key, weight := range weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 1) {
+			return nil, fmt.Errorf("%w: weight of %s", ErrNotNumeric, key)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("%w: weight of %s", ErrNegativeMetric, key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			for _, key := range keys {
+				tag, found := edge.Tags[key]
+				if !found {
+					continue
+				}
+				value, ok := toFloat64(tag)
+				if !ok || math.IsNaN(value) || math.IsInf(value, 1) {
+					return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, key, edge.From.ID, edge.To.ID)
+				}
+				if value < 0 {
+					return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNegativeMetric, key, edge.From.ID, edge.To.ID)
+				}
+			}
+		}
+	}
+
+	q := newQuery(nil)
+	q.costFunc = func(e Edge) uint64 {
+		//All the values were validated above, missing ones are 0,
+		//and the keys are sorted so that the sum is deterministic
+		var sum float64
+		for _, key := range keys {
+			value, _ := toFloat64(e.Tags[key])
+			sum += weights[key] * value
+		}
+		return scaleMetric(1, sum)
+	}
+	return g.spf(from, to, q)
+}
+
+// ExpectedCostPath returns one path from one vertex to the other
+// that minimizes the expected cost of the traffic, given the
+// probability that every edge fails, stored in its numeric tag
+// failProbTag. Every failure costs failurePenalty, so the
+// effective weight of an edge with cost c and probability p is
+//
+//	c + failurePenalty * p
+//
+// rounded to the nearest integer, and the weight of a path is its
+// cost plus failurePenalty times its expected number of failures.
+// The weighted sum saturates at the largest uint64 value, and such
+// edges are treated as unreachable.
+// Edges without the tag never fail. A probability that is not a
+// number or is not within [0, 1] makes the query fail with
+// ErrNotNumeric or ErrInvalidProbability, and a negative penalty
+// with ErrNegativeMetric.
+// Among paths of equal weight, the smallest predecessor edge of
+// every vertex is followed. The path is empty if the destination
+// cannot be reached.
+func (g *Graph) ExpectedCostPath(from, to Vertex, failProbTag string, failurePenalty float64) ([]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	if failurePenalty < 0 || math.IsNaN(failurePenalty) {
+		return nil, fmt.Errorf("%w: failure penalty %v", ErrNegativeMetric, failurePenalty)
+	}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			tag, found := edge.Tags[failProbTag]
+			if !found {
+				continue
+			}
+			p, ok := toFloat64(tag)
+			if !ok {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, failProbTag, edge.From.ID, edge.To.ID)
+			}
+			if p < 0 || p > 1 || math.IsNaN(p) {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrInvalidProbability, failProbTag, edge.From.ID, edge.To.ID)
+			}
+		}
+	}
+
+	q := newQuery(nil)
+	q.costFunc = func(e Edge) uint64 {
+		//All the values were validated above, missing ones are 0
+		p, _ := toFloat64(e.Tags[failProbTag])
+		penalty := math.Round(failurePenalty * p)
+		if penalty >= math.MaxUint64 {
+			return infinity
+		}
+		return addCost(e.Cost, uint64(penalty))
+	}
+	path, ok := g.shortestPath(from, to, q)
+	if !ok {
+		return []Edge{}, nil
+	}
+	return path, nil
+}
+
+// scaleMetric returns coefficient times value rounded to
+// the nearest integer, saturating at infinity.
+func scaleMetric(coefficient uint64, value float64) uint64 {
+	scaled := math.Round(float64(coefficient) * value)
+	if scaled >= math.MaxUint64 {
+		return infinity
+	}
+	return uint64(scaled)
+}
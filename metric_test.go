@@ -0,0 +1,223 @@
+package cspf_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSPFBlended(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	latency := func(ms float64) cspf.Tag {
+		return cspf.Tag{Key: "latency", Value: ms}
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a cheap but slow path and an expensive but fast one", t, func() {
+		So(graph.AddEdge(a, b, 1, latency(20)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, latency(20)), ShouldBeNil)
+		So(graph.AddEdge(a, c, 10, latency(1)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 10, latency(1.5)), ShouldBeNil)
+	})
+
+	route := func(alpha, beta uint64) string {
+		spf, err := graph.SPFBlended(a, d, alpha, "latency", beta)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		return cspf.Path(paths[0]).String()
+	}
+
+	Convey("Changing the coefficients flips the chosen path", t, func() {
+		So(route(1, 0), ShouldEqual, "a->b->d")
+		So(route(0, 1), ShouldEqual, "a->c->d")
+		So(route(10, 1), ShouldEqual, "a->b->d")
+		So(route(1, 10), ShouldEqual, "a->c->d")
+	})
+
+	Convey("The weighted sum saturates instead of overflowing", t, func() {
+		//The edges of the fast path would overflow and wrap around
+		So(route(math.MaxUint64/4, 0), ShouldEqual, "a->b->d")
+	})
+
+	Convey("Every edge needs a non-negative numeric metric", t, func() {
+		bad := cspf.Graph{}
+		So(bad.AddEdge(a, b, 1, cspf.Tag{Key: "latency", Value: "slow"}), ShouldBeNil)
+		_, err := bad.SPFBlended(a, b, 1, "latency", 1)
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+
+		negative := cspf.Graph{}
+		So(negative.AddEdge(a, b, 1, latency(-1)), ShouldBeNil)
+		_, err = negative.SPFBlended(a, b, 1, "latency", 1)
+		So(errors.Is(err, cspf.ErrNegativeMetric), ShouldBeTrue)
+
+		for _, value := range []interface{}{math.NaN(), "NaN", math.Inf(1)} {
+			notFinite := cspf.Graph{}
+			So(notFinite.AddEdge(a, b, 1, cspf.Tag{Key: "latency", Value: value}), ShouldBeNil)
+			_, err = notFinite.SPFBlended(a, b, 1, "latency", 1)
+			So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+		}
+	})
+
+	Convey("Disabled edges are neither traversed nor checked", t, func() {
+		disabled := cspf.Graph{}
+		So(disabled.AddEdge(a, b, 1, latency(1)), ShouldBeNil)
+		So(disabled.AddEdge(a, c, 1), ShouldBeNil)
+		So(disabled.SetEdgeEnabled(a, c, false), ShouldEqual, 1)
+		spf, err := disabled.SPFBlended(a, b, 1, "latency", 1)
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, b), ShouldResemble, []string{"a->b"})
+	})
+
+	Convey("Call SPFBlended on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFBlended(a, d, 1, "latency", 1)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFWeightedMetrics(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	delay := func(ms float64) cspf.Tag {
+		return cspf.Tag{Key: "delay", Value: ms}
+	}
+	loss := func(pct float64) cspf.Tag {
+		return cspf.Tag{Key: "loss", Value: pct}
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a fast but lossy path and a slow but clean one", t, func() {
+		So(graph.AddEdge(a, b, 1, delay(10), loss(0.1)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, delay(10), loss(0.1)), ShouldBeNil)
+		//A missing loss tag counts as zero loss
+		So(graph.AddEdge(a, c, 1, delay(30), loss(0)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, delay(30)), ShouldBeNil)
+	})
+
+	route := func(weights map[string]float64) string {
+		spf, err := graph.SPFWeightedMetrics(a, d, weights)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		return cspf.Path(paths[0]).String()
+	}
+
+	Convey("Changing the weights flips the chosen path", t, func() {
+		//20 against 60
+		So(route(map[string]float64{"delay": 1}), ShouldEqual, "a->b->d")
+		//20 + 200 against 60
+		So(route(map[string]float64{"delay": 1, "loss": 1000}), ShouldEqual, "a->c->d")
+		//The edges of the result graph keep their own cost
+		spf, err := graph.SPFWeightedMetrics(a, d, map[string]float64{"delay": 1})
+		So(err, ShouldBeNil)
+		So(spf.VertexSet[a][0].Cost, ShouldEqual, 1)
+	})
+
+	Convey("Weights and metrics must be non-negative numbers", t, func() {
+		_, err := graph.SPFWeightedMetrics(a, d, map[string]float64{"delay": -1})
+		So(errors.Is(err, cspf.ErrNegativeMetric), ShouldBeTrue)
+
+		bad := cspf.Graph{}
+		So(bad.AddEdge(a, b, 1, cspf.Tag{Key: "delay", Value: "slow"}), ShouldBeNil)
+		_, err = bad.SPFWeightedMetrics(a, b, map[string]float64{"delay": 1})
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+
+		negative := cspf.Graph{}
+		So(negative.AddEdge(a, b, 1, delay(-1)), ShouldBeNil)
+		_, err = negative.SPFWeightedMetrics(a, b, map[string]float64{"delay": 1})
+		So(errors.Is(err, cspf.ErrNegativeMetric), ShouldBeTrue)
+
+		for _, weight := range []float64{math.NaN(), math.Inf(1)} {
+			_, err = graph.SPFWeightedMetrics(a, d, map[string]float64{"delay": weight})
+			So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+		}
+
+		for _, value := range []interface{}{math.NaN(), "NaN", math.Inf(1)} {
+			notFinite := cspf.Graph{}
+			So(notFinite.AddEdge(a, b, 1, cspf.Tag{Key: "delay", Value: value}), ShouldBeNil)
+			_, err = notFinite.SPFWeightedMetrics(a, b, map[string]float64{"delay": 1})
+			So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+		}
+	})
+
+	Convey("Call SPFWeightedMetrics on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFWeightedMetrics(a, d, nil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestExpectedCostPath(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+	failure := func(p float64) cspf.Tag {
+		return cspf.Tag{Key: "failure", Value: p}
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a cheap but fragile path and a reliable but longer one", t, func() {
+		So(graph.AddEdge(a, b, 1, failure(0.2)), ShouldBeNil)
+		So(graph.AddEdge(b, e, 1, failure(0.3)), ShouldBeNil)
+		So(graph.AddEdge(a, c, 3, failure(0.01)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 3), ShouldBeNil)
+		So(graph.AddEdge(d, e, 3, failure(0.01)), ShouldBeNil)
+	})
+
+	route := func(penalty float64) string {
+		path, err := graph.ExpectedCostPath(a, e, "failure", penalty)
+		So(err, ShouldBeNil)
+		return cspf.Path(path).String()
+	}
+
+	Convey("The failure penalty flips the chosen path", t, func() {
+		//Without penalty, the cheapest path wins
+		So(route(0), ShouldEqual, "a->b->e")
+		//2 + 10*0.5 = 7 beats 9 + 10*0.02
+		So(route(10), ShouldEqual, "a->b->e")
+		//2 + 100*0.5 = 52 loses to 9 + 100*0.02 = 11
+		So(route(100), ShouldEqual, "a->c->d->e")
+	})
+
+	Convey("The path is empty if the destination cannot be reached", t, func() {
+		path, err := graph.ExpectedCostPath(e, a, "failure", 100)
+		So(err, ShouldBeNil)
+		So(path, ShouldBeEmpty)
+	})
+
+	Convey("Invalid probabilities and penalties are reported as errors", t, func() {
+		bad := cspf.Graph{}
+		So(bad.AddEdge(a, b, 1, cspf.Tag{Key: "failure", Value: "often"}), ShouldBeNil)
+		_, err := bad.ExpectedCostPath(a, b, "failure", 1)
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+
+		outOfRange := cspf.Graph{}
+		So(outOfRange.AddEdge(a, b, 1, failure(1.5)), ShouldBeNil)
+		_, err = outOfRange.ExpectedCostPath(a, b, "failure", 1)
+		So(errors.Is(err, cspf.ErrInvalidProbability), ShouldBeTrue)
+
+		_, err = graph.ExpectedCostPath(a, e, "failure", -1)
+		So(errors.Is(err, cspf.ErrNegativeMetric), ShouldBeTrue)
+	})
+
+	Convey("Call ExpectedCostPath on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.ExpectedCostPath(a, e, "failure", 1)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
@@ -0,0 +1,76 @@
+package cspf
+
+// MutationKind tells which operation changed the graph.
+type MutationKind int
+
+const (
+	// MutationAddNode is the addition of a vertex through AddNode.
+	MutationAddNode MutationKind = iota
+	// MutationAddEdge is the addition of an edge.
+	MutationAddEdge
+	// MutationRemoveEdge is the removal of an edge.
+	MutationRemoveEdge
+	// MutationUpdateEdgeCost is the change of the cost of an edge.
+	MutationUpdateEdgeCost
+	// MutationSetEdgeEnabled is the change of the state of an edge.
+	MutationSetEdgeEnabled
+	// MutationSetVertexDefaultTag is the change of a default tag
+	// of a vertex through SetVertexDefaultTag.
+	MutationSetVertexDefaultTag
+	// MutationUpdateEdge is any other change of an edge, such
+	// as the merge of tags by AddOrUpdateEdge or the merge of
+	// parallel edges by CollapseParallelEdges.
+	MutationUpdateEdge
+	// MutationRemoveVertex is the removal of a vertex by Prune,
+	// recorded after the removal of all its edges.
+	MutationRemoveVertex
+)
+
+// Mutation is an entry of the log of the changes made
+// to a graph that has RecordMutations set.
+type Mutation struct {
+	// Kind of the change.
+	Kind MutationKind
+	// Vertex added by a MutationAddNode, removed by a
+	// MutationRemoveVertex, or whose default tag is set by a
+	// MutationSetVertexDefaultTag.
+	Vertex Vertex
+	// Tag set by a MutationSetVertexDefaultTag.
+	Tag Tag
+	// Edge added, removed or updated by the other kinds.
+	// Updated edges are recorded with their new cost or state.
+	Edge Edge
+	// Previous is the updated edge as it was before the
+	// change, for MutationUpdateEdgeCost, MutationSetEdgeEnabled
+	// and MutationUpdateEdge, so that replaying the log can
+	// tell it apart from its parallel edges.
+	Previous Edge
+}
+
+// Mutations returns the changes recorded so far, from the
+// oldest to the newest. Changes are recorded only while the
+// RecordMutations flag of the graph is set. Applying them in
+// order to an empty graph builds a graph Equal to this one.
+func (g *Graph) Mutations() []Mutation {
+	if g == nil {
+		return nil
+	}
+	mutations := make([]Mutation, len(g.mutations))
+	copy(mutations, g.mutations)
+	return mutations
+}
+
+// record appends a change to the log if recording is enabled.
+func (g *Graph) record(m Mutation) {
+	if g.RecordMutations {
+		g.mutations = append(g.mutations, m)
+	}
+}
+
+// recordUpdate records the change of an edge from
+// previous to edge, if it changed at all.
+func (g *Graph) recordUpdate(kind MutationKind, previous, edge Edge) {
+	if !previous.equal(edge) || previous.ID != edge.ID {
+		g.record(Mutation{Kind: kind, Edge: edge, Previous: previous})
+	}
+}
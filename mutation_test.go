@@ -0,0 +1,194 @@
+package cspf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// replay applies a log of mutations to an empty graph. It
+// returns nil if a mutation refers to an edge the graph lacks.
+func replay(mutations []cspf.Mutation) *cspf.Graph {
+	graph := cspf.Graph{}
+	//replace swaps the first edge deeply equal to old with
+	//the given one, or removes it if the given one is nil
+	replace := func(old cspf.Edge, edge *cspf.Edge) bool {
+		edges := graph.VertexSet[old.From]
+		for i := range edges {
+			if !reflect.DeepEqual(edges[i], old) {
+				continue
+			}
+			if edge == nil {
+				graph.VertexSet[old.From] = append(edges[:i:i], edges[i+1:]...)
+			} else {
+				edges[i] = *edge
+			}
+			return true
+		}
+		return false
+	}
+	for _, m := range mutations {
+		m := m
+		ok := true
+		switch m.Kind {
+		case cspf.MutationAddNode:
+			graph.AddNode(m.Vertex)
+		case cspf.MutationAddEdge:
+			graph.AddNode(m.Edge.From)
+			graph.AddNode(m.Edge.To)
+			graph.VertexSet[m.Edge.From] = append(graph.VertexSet[m.Edge.From], m.Edge)
+		case cspf.MutationRemoveEdge:
+			ok = replace(m.Edge, nil)
+		case cspf.MutationUpdateEdgeCost, cspf.MutationSetEdgeEnabled, cspf.MutationUpdateEdge:
+			ok = replace(m.Previous, &m.Edge)
+		case cspf.MutationSetVertexDefaultTag:
+			graph.SetVertexDefaultTag(m.Vertex, m.Tag.Key, m.Tag.Value)
+		case cspf.MutationRemoveVertex:
+			delete(graph.VertexSet, m.Vertex)
+		}
+		if !ok {
+			return nil
+		}
+	}
+	return &graph
+}
+
+func TestMutations(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	Convey("Mutations are not recorded by default", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		graph.AddNode(c)
+		So(graph.Mutations(), ShouldBeEmpty)
+	})
+
+	Convey("Record the sequence of mutations", t, func() {
+		graph := cspf.Graph{RecordMutations: true}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		graph.AddNode(c)
+		//Adding an existing vertex changes nothing
+		graph.AddNode(a)
+		So(graph.AddEdge(a, b, 2), ShouldBeNil)
+		So(graph.RemoveEdge(a, b), ShouldEqual, 2)
+		So(graph.RemoveEdge(b, c), ShouldEqual, 0)
+
+		ab1 := cspf.Edge{From: a, To: b, Cost: 1}
+		ab2 := cspf.Edge{From: a, To: b, Cost: 2}
+		So(graph.Mutations(), ShouldResemble, []cspf.Mutation{
+			{Kind: cspf.MutationAddEdge, Edge: ab1},
+			{Kind: cspf.MutationAddNode, Vertex: c},
+			{Kind: cspf.MutationAddEdge, Edge: ab2},
+			{Kind: cspf.MutationRemoveEdge, Edge: ab1},
+			{Kind: cspf.MutationRemoveEdge, Edge: ab2},
+		})
+		So(graph.VertexSet[a], ShouldBeEmpty)
+	})
+
+	Convey("Call Mutations on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Mutations(), ShouldBeNil)
+		So(nilGraph.RemoveEdge(a, b), ShouldEqual, 0)
+	})
+}
+
+func TestReplayMutations(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+	tagFast := cspf.Tag{
+		Key:   "speed",
+		Value: "fast",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{RecordMutations: true}
+
+	steps := []struct {
+		name   string
+		mutate func()
+	}{
+		{"AddEdge", func() {
+			So(graph.AddEdge(a, b, 4, tagBlue), ShouldBeNil)
+			So(graph.AddEdge(a, b, 6), ShouldBeNil)
+			So(graph.AddEdge(b, c, 2, tagRed), ShouldBeNil)
+			So(graph.AddEdge(c, c, 1), ShouldBeNil)
+			So(graph.AddEdge(c, d, 8), ShouldBeNil)
+		}},
+		{"AddEdgeWithID", func() {
+			So(graph.AddEdgeWithID("x", b, d, 3), ShouldBeNil)
+		}},
+		{"AddNode", func() {
+			graph.AddNode(e)
+		}},
+		{"AddOrUpdateEdge", func() {
+			So(graph.AddOrUpdateEdge(a, b, 4, tagFast), ShouldBeNil)
+		}},
+		{"UpdateEdgeCost", func() {
+			So(graph.UpdateEdgeCost(c, d, 7), ShouldEqual, 1)
+			So(graph.UpdateEdgeCostByID("x", 5), ShouldBeNil)
+		}},
+		{"SetEdgeEnabled", func() {
+			So(graph.SetEdgeEnabled(b, c, false), ShouldEqual, 1)
+		}},
+		{"SetVertexDefaultTag", func() {
+			graph.SetVertexDefaultTag(c, "link", "blue")
+		}},
+		{"NormalizeCosts", func() {
+			graph.NormalizeCosts(16)
+		}},
+		{"MapCosts", func() {
+			graph.MapCosts(func(edge cspf.Edge) uint64 { return edge.Cost + 1 })
+		}},
+		{"CollapseParallelEdges", func() {
+			graph.CollapseParallelEdges(func(x, y cspf.Edge) cspf.Edge {
+				x.Cost += y.Cost
+				return x
+			})
+		}},
+		{"Prune", func() {
+			result := graph.Prune(cspf.PruneOptions{
+				RemoveSelfLoops:        true,
+				RemoveDeadEnds:         true,
+				RemoveIsolatedVertices: true,
+			})
+			So(result, ShouldResemble, cspf.PruneResult{SelfLoops: 1, IsolatedVertices: 1, DeadEnds: 1})
+		}},
+		{"RemoveEdgesWithTag", func() {
+			So(graph.RemoveEdgesWithTag("link", "red"), ShouldEqual, 1)
+		}},
+		{"RemoveEdge", func() {
+			So(graph.RemoveEdge(a, b), ShouldEqual, 1)
+			So(graph.AddEdgeWithID("y", a, c, 1), ShouldBeNil)
+			So(graph.RemoveEdgeByID("y"), ShouldBeNil)
+		}},
+		{"SPFInto", func() {
+			source := cspf.Graph{}
+			So(source.AddEdge(d, e, 2, tagRed), ShouldBeNil)
+			So(source.SPFInto(&graph, d, e), ShouldBeNil)
+		}},
+	}
+	for _, step := range steps {
+		Convey("Replay the log after "+step.name, t, func() {
+			step.mutate()
+			replayed := replay(graph.Mutations())
+			So(replayed, ShouldNotBeNil)
+			So(replayed.Equal(&graph), ShouldBeTrue)
+		})
+	}
+}
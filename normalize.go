@@ -0,0 +1,152 @@
+package cspf
+
+import "math/bits"
+
+// NormalizeCosts rescales the costs of all the edges of the
+// graph so that the largest one becomes maxOut, e.g. to compare
+// graphs that come from sources with different metric scales.
+// Every cost c is replaced by c * maxOut / max, rounded to the
+// nearest integer with halves rounded up. The relative order of
+// the costs is preserved, but when shrinking the range distinct
+// costs may collapse to the same value, and small costs may
+// become zero. A graph whose costs are all zero is left untouched.
+func (g *Graph) NormalizeCosts(maxOut uint64) {
+	if g == nil {
+		return
+	}
+	var max uint64
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.Cost > max {
+				max = edge.Cost
+			}
+		}
+	}
+	if max == 0 {
+		return
+	}
+	for _, edges := range g.VertexSet {
+		for i := range edges {
+			previous := edges[i]
+			edges[i].Cost = scaleCost(edges[i].Cost, maxOut, max)
+			g.recordUpdate(MutationUpdateEdgeCost, previous, edges[i])
+		}
+	}
+}
+
+// WithNormalizedCosts is the same as NormalizeCosts, but it
+// returns a normalized copy of the graph and leaves the graph
+// itself unchanged.
+func (g *Graph) WithNormalizedCosts(maxOut uint64) *Graph {
+	if g == nil {
+		return nil
+	}
+	normalized := g.clone()
+	normalized.NormalizeCosts(maxOut)
+	return normalized
+}
+
+// MapCosts replaces the cost of every edge of the graph with the
+// cost f returns for it, e.g. to double all the costs or to add
+// some jitter for a sensitivity analysis. The edge passed to f
+// has its original cost, and f is called once per edge.
+func (g *Graph) MapCosts(f func(Edge) uint64) {
+	if g == nil {
+		return
+	}
+	for _, edges := range g.VertexSet {
+		for i := range edges {
+			previous := edges[i]
+			edges[i].Cost = f(edges[i])
+			g.recordUpdate(MutationUpdateEdgeCost, previous, edges[i])
+		}
+	}
+}
+
+// WithMappedCosts is the same as MapCosts, but it returns a
+// copy of the graph with the new costs and leaves the graph
+// itself unchanged.
+func (g *Graph) WithMappedCosts(f func(Edge) uint64) *Graph {
+	if g == nil {
+		return nil
+	}
+	mapped := g.clone()
+	mapped.MapCosts(f)
+	return mapped
+}
+
+// scaleCost returns cost * num / den rounded to the nearest integer,
+// computing the product on 128 bits so that it cannot overflow.
+// It requires cost <= den.
+func scaleCost(cost, num, den uint64) uint64 {
+	hi, lo := bits.Mul64(cost, num)
+	quo, rem := bits.Div64(hi, lo, den)
+	if rem >= den-rem {
+		quo++
+	}
+	return quo
+}
+
+// Normalize repairs the vertex set of a graph that was edited
+// by hand, consolidating the vertices by ID: every edge is filed
+// under the vertex with the ID of its source, whatever the key it
+// was found under, the edge lists of the vertices sharing an ID
+// are merged, and the destinations of the edges are added to the
+// graph if missing. Edges keep their relative order within the
+// lists they come from, which are merged by vertex ID.
+// Graphs built through the methods of the package are left as
+// they are.
+func (g *Graph) Normalize() {
+	if g == nil || g.VertexSet == nil {
+		return
+	}
+	normalized := make(map[Vertex][]Edge, len(g.VertexSet))
+	for _, v := range g.Vertices() {
+		v = Vertex{ID: v.ID}
+		if _, ok := normalized[v]; !ok {
+			normalized[v] = []Edge{}
+		}
+	}
+	for _, v := range g.Vertices() {
+		for _, edge := range g.VertexSet[v] {
+			edge.From = Vertex{ID: edge.From.ID}
+			edge.To = Vertex{ID: edge.To.ID}
+			normalized[edge.From] = append(normalized[edge.From], edge)
+			if _, ok := normalized[edge.To]; !ok {
+				normalized[edge.To] = []Edge{}
+			}
+		}
+	}
+	g.VertexSet = normalized
+}
+
+// clone returns a deep copy of the graph, including
+// the tags of every edge and the default tags of the vertices.
+func (g *Graph) clone() *Graph {
+	copied := Graph{}
+	for v, edges := range g.VertexSet {
+		copied.AddNode(v)
+		for _, edge := range edges {
+			copied.addEdge(edge.withTagsCopy())
+		}
+	}
+	for v, tags := range g.vertexTags {
+		for key, value := range tags {
+			copied.SetVertexDefaultTag(v, key, value)
+		}
+	}
+	return &copied
+}
+
+// withTagsCopy returns the edge with a copy of its tags,
+// so that the returned edge shares no state with it.
+func (e Edge) withTagsCopy() Edge {
+	if e.Tags != nil {
+		tags := make(map[string]interface{}, len(e.Tags))
+		for key, value := range e.Tags {
+			tags[key] = value
+		}
+		e.Tags = tags
+	}
+	return e
+}
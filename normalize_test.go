@@ -0,0 +1,158 @@
+package cspf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNormalizeCosts(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 10), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1000), ShouldBeNil)
+		So(graph.AddEdge(c, d, 255), ShouldBeNil)
+		So(graph.AddEdge(a, d, 4), ShouldBeNil)
+	})
+
+	Convey("Normalize a copy of the graph", t, func() {
+		normalized := graph.WithNormalizedCosts(100)
+		So(normalized.VertexSet[b][0].Cost, ShouldEqual, 100)
+		So(normalized.VertexSet[a][0].Cost, ShouldEqual, 1)
+		//25.5 is rounded up
+		So(normalized.VertexSet[c][0].Cost, ShouldEqual, 26)
+		//0.4 is rounded down
+		So(normalized.VertexSet[a][1].Cost, ShouldEqual, 0)
+
+		Convey("The original graph is unchanged", func() {
+			So(graph.VertexSet[b][0].Cost, ShouldEqual, 1000)
+		})
+	})
+
+	Convey("Normalize the graph in place", t, func() {
+		graph.NormalizeCosts(math.MaxUint64)
+		So(graph.VertexSet[b][0].Cost, ShouldEqual, uint64(math.MaxUint64))
+		So(graph.VertexSet[a][1].Cost, ShouldBeLessThan, graph.VertexSet[a][0].Cost)
+		So(graph.VertexSet[a][0].Cost, ShouldBeLessThan, graph.VertexSet[c][0].Cost)
+		So(graph.VertexSet[c][0].Cost, ShouldBeLessThan, graph.VertexSet[b][0].Cost)
+	})
+
+	Convey("A graph with only zero costs is left untouched", t, func() {
+		zero := cspf.Graph{}
+		So(zero.AddEdge(a, b, 0), ShouldBeNil)
+		zero.NormalizeCosts(100)
+		So(zero.VertexSet[a][0].Cost, ShouldEqual, 0)
+	})
+
+	Convey("Call WithNormalizedCosts on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		nilGraph.NormalizeCosts(100)
+		So(nilGraph.WithNormalizedCosts(100), ShouldBeNil)
+	})
+}
+
+func TestMapCosts(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 2), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2), ShouldBeNil)
+		So(graph.AddEdge(c, d, 3), ShouldBeNil)
+	})
+
+	double := func(e cspf.Edge) uint64 {
+		return 2 * e.Cost
+	}
+
+	Convey("Doubling a copy keeps the path and doubles its cost", t, func() {
+		doubled := graph.WithMappedCosts(double)
+		before, err := graph.SPF(a, d)
+		So(err, ShouldBeNil)
+		after, err := doubled.SPF(a, d)
+		So(err, ShouldBeNil)
+		So(after.PathStrings(a, d), ShouldResemble, before.PathStrings(a, d))
+		So(cspf.Path(after.Paths(a, d)[0]).Cost(), ShouldEqual, 2*cspf.Path(before.Paths(a, d)[0]).Cost())
+		//The graph itself is unchanged
+		So(graph.VertexSet[a][0].Cost, ShouldEqual, 1)
+	})
+
+	Convey("Map the costs in place", t, func() {
+		graph.MapCosts(double)
+		So(graph.VertexSet[a][0].Cost, ShouldEqual, 2)
+		So(graph.VertexSet[c][0].Cost, ShouldEqual, 6)
+		graph.MapCosts(func(e cspf.Edge) uint64 {
+			if e.From == c {
+				return 0
+			}
+			return e.Cost
+		})
+		spf, err := graph.SPF(a, d)
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Map the costs of a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(func() { nilGraph.MapCosts(double) }, ShouldNotPanic)
+		So(nilGraph.WithMappedCosts(double), ShouldBeNil)
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	Convey("Consolidate a graph edited by hand", t, func() {
+		//The edge from c is filed under a, and neither c nor d are keys
+		graph := cspf.Graph{VertexSet: map[cspf.Vertex][]cspf.Edge{
+			a: {{From: a, To: b, Cost: 1}, {From: c, To: d, Cost: 2}},
+			b: nil,
+			c: {{From: c, To: a, Cost: 3}},
+		}}
+		graph.Normalize()
+		So(graph.VertexSet, ShouldResemble, map[cspf.Vertex][]cspf.Edge{
+			a: {{From: a, To: b, Cost: 1}},
+			b: {},
+			//The edges found under a come first
+			c: {{From: c, To: d, Cost: 2}, {From: c, To: a, Cost: 3}},
+			d: {},
+		})
+		So(graph.PathStrings(a, d), ShouldBeEmpty)
+		So(graph.PathStrings(c, d), ShouldResemble, []string{"c->d"})
+	})
+
+	Convey("Leave a graph built by the methods as it is", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		graph.AddNode(d)
+		before := graph.Canonical()
+		graph.Normalize()
+		So(graph.Canonical(), ShouldEqual, before)
+	})
+
+	Convey("Normalize an empty and a nil graph", t, func() {
+		empty := cspf.Graph{}
+		empty.Normalize()
+		So(empty.VertexSet, ShouldBeNil)
+		var nilGraph *cspf.Graph
+		So(nilGraph.Normalize, ShouldNotPanic)
+	})
+}
@@ -0,0 +1,258 @@
+package cspf
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PaesslerAG/gval"
+)
+
+// Option customizes the behavior of a single query
+// run on a graph, such as SPF or CSPF, or of a single
+// batch operation, such as AddEdges.
+type Option func(*query)
+
+// query holds the settings of a single query or operation.
+type query struct {
+	// Compiled constraint expression, if any.
+	eval gval.Evaluable
+	// Record one predecessor per vertex only.
+	singlePath bool
+	// Called for edges whose destination was already visited.
+	onSkippedEdge func(Edge)
+	// User functions available to the expression, by name.
+	functions map[string]interface{}
+	// Keep going after the failures of a batch operation.
+	collectErrors bool
+	// Edges must satisfy all the filters to be traversed.
+	filters []func(Edge) bool
+	// Effective cost of the edges, if not their own cost.
+	costFunc func(Edge) uint64
+	// Cost added to the avoided edges of a reroute.
+	avoidPenalty uint64
+	// Outcome of the expression on the edges evaluated so far.
+	evalCache map[edgeRef]bool
+	// Conversion of the numeric tags before evaluation.
+	normalizer NumericTagNormalizer
+	// Longest evaluation of the expression on an edge, if any.
+	evalTimeout time.Duration
+	// Default tags of the edges leaving every vertex.
+	vertexTags map[Vertex]map[string]interface{}
+	// Fail the query if the destination cannot be reached.
+	noPathError bool
+}
+
+func newQuery(opts []Option) *query {
+	q := &query{}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// cost returns the cost of an edge for this query.
+func (q *query) cost(e Edge) uint64 {
+	if q.costFunc != nil {
+		return q.costFunc(e)
+	}
+	return e.Cost
+}
+
+// compile compiles the constraint expression of a query
+// to run on the graph, which provides the default tags
+// the edges inherit from their source vertex.
+func (g *Graph) compile(q *query, exp string) error {
+	q.vertexTags = g.vertexTags
+	return q.compile(exp)
+}
+
+// compile parses the constraint expression of the query
+// in the CSPF language, extended with the user functions.
+func (q *query) compile(exp string) error {
+	lang := language
+	if len(q.functions) > 0 {
+		names := make([]string, 0, len(q.functions))
+		for name := range q.functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		extensions := []gval.Language{language}
+		for _, name := range names {
+			extensions = append(extensions, gval.Function(name, q.functions[name]))
+		}
+		lang = gval.NewLanguage(extensions...)
+	}
+	eval, err := lang.NewEvaluable(exp)
+	if err != nil {
+		return err
+	}
+	q.eval = eval
+	return nil
+}
+
+// evalBool evaluates the expression of the query on the
+// parameters of an edge, within the timeout of the query.
+func (q *query) evalBool(params map[string]interface{}) (bool, error) {
+	if q.evalTimeout <= 0 {
+		return q.eval.EvalBool(context.Background(), params)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), q.evalTimeout)
+	defer cancel()
+	type outcome struct {
+		match bool
+		err   error
+	}
+	//Buffered, so that a late evaluation does not block forever
+	done := make(chan outcome, 1)
+	go func() {
+		match, err := q.eval.EvalBool(ctx, params)
+		done <- outcome{match, err}
+	}()
+	select {
+	case o := <-done:
+		return o.match, o.err
+	case <-ctx.Done():
+		return false, fmt.Errorf("%w: after %v", ErrEvalTimeout, q.evalTimeout)
+	}
+}
+
+// WithSinglePath makes the query behave like the classic
+// Dijkstra algorithm: only the first shortest path found
+// to every vertex is recorded, so the result graph is a
+// shortest-path tree without equal-cost alternatives.
+func WithSinglePath() Option {
+	return func(q *query) {
+		q.singlePath = true
+	}
+}
+
+// WithSkippedEdgeObserver registers a callback that is
+// notified of every edge the query does not relax because
+// its destination vertex has already been visited, i.e.
+// its shortest distance from the source is already final.
+// Such edges can never be part of the result graph.
+func WithSkippedEdgeObserver(observer func(Edge)) Option {
+	return func(q *query) {
+		q.onSkippedEdge = observer
+	}
+}
+
+// WithFunction makes a user-defined function available
+// to the constraint expression of the query under the
+// given name, e.g. prefixMatch(network, "10.0.0.0/8").
+//
+// The function is called with the evaluated arguments of the
+// call: identifiers resolve to the values of the edge's tags
+// with the same key (nil if the edge has no such tag), number
+// literals are float64, and string and bool literals keep
+// their type. Each argument must be assignable to the type
+// of the corresponding parameter, so interface{} parameters
+// accept any tag value. The function may return an error as
+// its last result, which aborts the query.
+// Functions override the built-in ones with the same name.
+func WithFunction(name string, function interface{}) Option {
+	return func(q *query) {
+		if q.functions == nil {
+			q.functions = make(map[string]interface{})
+		}
+		q.functions[name] = function
+	}
+}
+
+// WithCollectErrors makes batch operations process every
+// item, instead of stopping at the first failure, and return
+// a *BatchError that lists all the failures. The valid items
+// of the batch are applied even if some others fail.
+func WithCollectErrors() Option {
+	return func(q *query) {
+		q.collectErrors = true
+	}
+}
+
+// WithAvoidPenalty makes RerouteAvoiding penalize the
+// edges to avoid, adding the given penalty to their cost,
+// instead of excluding them from the search.
+// A zero penalty does not mean that the edges are traversed
+// at no extra cost: it disables the option, so the edges to
+// avoid are excluded from the search as without it.
+func WithAvoidPenalty(penalty uint64) Option {
+	return func(q *query) {
+		q.avoidPenalty = penalty
+	}
+}
+
+// NumericTagNormalizer converts the value of a numeric tag
+// before the constraint expression is evaluated, e.g. to bring
+// tags measured in different units to the same unit.
+// It receives the key and the value of the tag, together with
+// all the original tags of the edge, so that it can look up a
+// companion tag such as the unit.
+type NumericTagNormalizer func(key string, value interface{}, tags map[string]interface{}) float64
+
+// WithNumericTagNormalizer makes the constraint expression of
+// the query see the values of the numeric tags of every edge as
+// converted by the given normalizer. Only tags holding an int,
+// uint or float value are converted: strings and other types are
+// passed to the expression unchanged. The tags stored in the
+// graph are not modified.
+func WithNumericTagNormalizer(normalizer NumericTagNormalizer) Option {
+	return func(q *query) {
+		q.normalizer = normalizer
+	}
+}
+
+// parameters returns the tags of an edge as
+// the expression of the query must see them.
+func (q *query) parameters(e Edge) map[string]interface{} {
+	tags := e.Tags
+	if defaults := q.vertexTags[e.From]; len(defaults) > 0 {
+		tags = make(map[string]interface{}, len(defaults)+len(e.Tags))
+		for key, value := range defaults {
+			tags[key] = value
+		}
+		for key, value := range e.Tags {
+			tags[key] = value
+		}
+	}
+	if q.normalizer == nil {
+		return tags
+	}
+	params := make(map[string]interface{}, len(tags))
+	for key, value := range tags {
+		if _, isString := value.(string); !isString {
+			if _, ok := toFloat64(value); ok {
+				value = q.normalizer(key, value, tags)
+			}
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// WithEvalTimeout bounds the time the constraint expression of
+// the query may take to evaluate on a single edge, e.g. to guard
+// against custom functions that hang. If an evaluation exceeds
+// the timeout, the query fails with ErrEvalTimeout.
+// The functions of the expression are not interrupted: a late
+// evaluation keeps running in the background until they return,
+// and its outcome is discarded.
+// Zero or a negative duration means no timeout.
+func WithEvalTimeout(d time.Duration) Option {
+	return func(q *query) {
+		q.evalTimeout = d
+	}
+}
+
+// WithNoPathError makes the queries that build a result graph,
+// such as SPF and CSPF, fail with ErrNoPath when the destination
+// cannot be reached from the source, and with ErrVertexNotFound
+// when either of them is not part of the graph. By default, such
+// queries succeed with a result graph that has no path between
+// the two vertices.
+func WithNoPathError() Option {
+	return func(q *query) {
+		q.noPathError = true
+	}
+}
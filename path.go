@@ -0,0 +1,226 @@
+package cspf
+
+import (
+	"sort"
+	"strings"
+)
+
+// Path is a sequence of edges where each edge starts
+// from the vertex the previous one ends at.
+type Path []Edge
+
+// Cost returns the sum of the costs of all the edges of the path.
+// The sum saturates at Unreachable instead of overflowing.
+func (p Path) Cost() uint64 {
+	var cost uint64
+	for _, edge := range p {
+		cost = addCost(cost, edge.Cost)
+	}
+	return cost
+}
+
+// Hops returns the number of edges of the path.
+func (p Path) Hops() int {
+	return len(p)
+}
+
+// Vertices returns the vertices traversed by the path,
+// from the first to the last one.
+// An empty path has no vertices.
+func (p Path) Vertices() []Vertex {
+	if len(p) == 0 {
+		return nil
+	}
+	vertices := make([]Vertex, 0, len(p)+1)
+	vertices = append(vertices, p[0].From)
+	for _, edge := range p {
+		vertices = append(vertices, edge.To)
+	}
+	return vertices
+}
+
+// Contains reports whether the path traverses the given vertex.
+func (p Path) Contains(v Vertex) bool {
+	for _, edge := range p {
+		if edge.From == v || edge.To == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSimplePath reports whether the edges form a path, i.e.
+// every edge starts from the vertex the previous one ends at,
+// and the path visits no vertex more than once.
+// It validates paths that were not listed by this package.
+// An empty sequence of edges is a simple path.
+func IsSimplePath(path []Edge) bool {
+	if len(path) == 0 {
+		return true
+	}
+	visited := map[Vertex]bool{path[0].From: true}
+	for i, edge := range path {
+		if i > 0 && edge.From != path[i-1].To {
+			return false
+		}
+		if visited[edge.To] {
+			return false
+		}
+		visited[edge.To] = true
+	}
+	return true
+}
+
+// String renders the path as the arrow-joined IDs of
+// its vertices, e.g. "A->B->D".
+// An empty path is rendered as an empty string.
+func (p Path) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return pathString(p[0].From, p)
+}
+
+// PathList is the same as Paths, but it returns
+// every path as a Path value.
+func (g *Graph) PathList(from, to Vertex) []Path {
+	paths := g.Paths(from, to)
+	if paths == nil {
+		return nil
+	}
+	list := make([]Path, 0, len(paths))
+	for _, path := range paths {
+		list = append(list, Path(path))
+	}
+	return list
+}
+
+// PathStep is an edge of a path together with the
+// cumulative cost of the path up to and including it.
+type PathStep struct {
+	// Edge is the traversed edge.
+	Edge Edge
+	// CumCost is the cost from the source to the end of the edge.
+	CumCost uint64
+}
+
+// PathsWithCumulativeCost is the same as Paths, but every
+// edge of every path carries the running total of the
+// cost from the source vertex.
+func (g *Graph) PathsWithCumulativeCost(from, to Vertex) [][]PathStep {
+	paths := g.Paths(from, to)
+	if paths == nil {
+		return nil
+	}
+	result := make([][]PathStep, 0, len(paths))
+	for _, path := range paths {
+		steps := make([]PathStep, 0, len(path))
+		var cost uint64
+		for _, edge := range path {
+			cost = addCost(cost, edge.Cost)
+			steps = append(steps, PathStep{Edge: edge, CumCost: cost})
+		}
+		result = append(result, steps)
+	}
+	return result
+}
+
+// NearShortestPaths lists all the simple paths that connect
+// from one vertex to the other over enabled edges and whose
+// cost is within the given percentage of the shortest path
+// cost, e.g. with a tolerance of 20 a path costing up to 1.2
+// times the optimum is listed. A negative tolerance is treated
+// as zero.
+// The enumeration is bounded: a branch is abandoned as soon
+// as it can no longer reach the destination within tolerance.
+// Paths are sorted by cost, cheapest first.
+func (g *Graph) NearShortestPaths(from, to Vertex, tolerancePct float64) ([][]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	//The distance of every vertex to <to> is the least
+	//cost needed to complete a path from that vertex
+	toDist, err := g.transpose().distances(to, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+	paths := [][]Edge{}
+	optimum, ok := toDist[from]
+	if !ok {
+		return paths, nil
+	}
+	if tolerancePct < 0 {
+		tolerancePct = 0
+	}
+	limit := float64(optimum) * (1 + tolerancePct/100)
+
+	err = g.walkPaths(from, to, func(_ edgeRef, edge Edge, hops int, cost uint64) (bool, error) {
+		remaining, ok := toDist[edge.To]
+		return ok && float64(addCost(cost, remaining)) <= limit, nil
+	}, func(path []Edge) {
+		paths = append(paths, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return Path(paths[i]).Cost() < Path(paths[j]).Cost()
+	})
+	return paths, nil
+}
+
+// PathsWithinCost lists all the simple paths that connect from
+// one vertex to the other over enabled edges and cost at most
+// maxCost, e.g. to offer alternate routes within a budget.
+// The enumeration is bounded: a branch is abandoned as soon
+// as its cost exceeds maxCost.
+// Paths are sorted by cost, cheapest first.
+func (g *Graph) PathsWithinCost(from, to Vertex, maxCost uint64) ([][]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	paths := [][]Edge{}
+	err := g.walkPaths(from, to, func(_ edgeRef, _ Edge, _ int, cost uint64) (bool, error) {
+		return cost <= maxCost, nil
+	}, func(path []Edge) {
+		paths = append(paths, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return Path(paths[i]).Cost() < Path(paths[j]).Cost()
+	})
+	return paths, nil
+}
+
+// pathSeparator joins vertex IDs when a path is rendered as a string.
+const pathSeparator = "->"
+
+// PathStrings lists all the paths of the graph that connect
+// from one vertex to the other, as returned by Paths, rendering
+// each of them as the arrow-joined IDs of the traversed
+// vertices, e.g. "A->B->D".
+func (g *Graph) PathStrings(from, to Vertex) []string {
+	paths := g.Paths(from, to)
+	if paths == nil {
+		return nil
+	}
+	strs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		strs = append(strs, pathString(from, path))
+	}
+	return strs
+}
+
+// pathString renders a path starting from the given
+// vertex as the arrow-joined IDs of its vertices.
+func pathString(from Vertex, path []Edge) string {
+	var b strings.Builder
+	b.WriteString(from.ID)
+	for _, edge := range path {
+		b.WriteString(pathSeparator)
+		b.WriteString(edge.To.ID)
+	}
+	return b.String()
+}
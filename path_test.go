@@ -0,0 +1,301 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPathStrings(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("Render the paths of the diamond as strings", t, func() {
+		So(graph.PathStrings(a, d), ShouldResemble, []string{"A->B->D", "A->C->D"})
+		So(graph.PathStrings(a, b), ShouldResemble, []string{"A->B"})
+		So(graph.PathStrings(a, a), ShouldResemble, []string{"A"})
+		So(graph.PathStrings(d, a), ShouldBeNil)
+	})
+
+	Convey("Call PathStrings on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.PathStrings(a, d), ShouldBeNil)
+	})
+}
+
+func TestPath(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2), ShouldBeNil)
+		So(graph.AddEdge(b, d, 3), ShouldBeNil)
+		So(graph.AddEdge(c, d, 4), ShouldBeNil)
+	})
+
+	Convey("List the paths as Path values", t, func() {
+		paths := graph.PathList(a, d)
+		So(len(paths), ShouldEqual, 2)
+		So(paths[0], ShouldResemble, cspf.Path(graph.Paths(a, d)[0]))
+
+		Convey("Cost sums the edge costs", func() {
+			So(paths[0].Cost(), ShouldEqual, 4)
+			So(paths[1].Cost(), ShouldEqual, 6)
+		})
+
+		Convey("Cost saturates instead of overflowing", func() {
+			huge := cspf.Path{{From: a, To: b, Cost: cspf.Unreachable - 1}, {From: b, To: d, Cost: 3}}
+			So(huge.Cost(), ShouldEqual, cspf.Unreachable)
+		})
+
+		Convey("Hops counts the edges", func() {
+			So(paths[0].Hops(), ShouldEqual, 2)
+			So(cspf.Path{}.Hops(), ShouldEqual, 0)
+		})
+
+		Convey("Vertices lists the traversed vertices", func() {
+			So(paths[1].Vertices(), ShouldResemble, []cspf.Vertex{a, c, d})
+			So(cspf.Path{}.Vertices(), ShouldBeNil)
+		})
+
+		Convey("Contains looks up traversed vertices", func() {
+			So(paths[0].Contains(a), ShouldBeTrue)
+			So(paths[0].Contains(b), ShouldBeTrue)
+			So(paths[0].Contains(d), ShouldBeTrue)
+			So(paths[0].Contains(c), ShouldBeFalse)
+		})
+
+		Convey("String renders the vertex IDs", func() {
+			So(paths[0].String(), ShouldEqual, "A->B->D")
+			So(paths[1].String(), ShouldEqual, "A->C->D")
+			So(cspf.Path{}.String(), ShouldEqual, "")
+		})
+	})
+
+	Convey("Call PathList on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.PathList(a, d), ShouldBeNil)
+	})
+}
+
+func TestNearShortestPaths(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 5), ShouldBeNil)
+		So(graph.AddEdge(b, e, 5), ShouldBeNil)
+		So(graph.AddEdge(a, c, 6), ShouldBeNil)
+		So(graph.AddEdge(c, e, 5), ShouldBeNil)
+		So(graph.AddEdge(a, d, 10), ShouldBeNil)
+		So(graph.AddEdge(d, e, 10), ShouldBeNil)
+	})
+
+	Convey("A 20% tolerance includes a slightly costlier alternate", t, func() {
+		paths, err := graph.NearShortestPaths(a, e, 20)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 2)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "A->B->E")
+		So(cspf.Path(paths[0]).Cost(), ShouldEqual, 10)
+		So(cspf.Path(paths[1]).String(), ShouldEqual, "A->C->E")
+		So(cspf.Path(paths[1]).Cost(), ShouldEqual, 11)
+	})
+
+	Convey("No tolerance only includes the shortest paths", t, func() {
+		paths, err := graph.NearShortestPaths(a, e, 0)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "A->B->E")
+	})
+
+	Convey("A large tolerance includes all the paths", t, func() {
+		paths, err := graph.NearShortestPaths(a, e, 100)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 3)
+		So(cspf.Path(paths[2]).String(), ShouldEqual, "A->D->E")
+	})
+
+	Convey("An unreachable destination has no paths", t, func() {
+		paths, err := graph.NearShortestPaths(e, a, 20)
+		So(err, ShouldBeNil)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("Disabled edges are never traversed", t, func() {
+		diamond := generateDisabledDiamond()
+		paths, err := diamond.NearShortestPaths(cspf.Vertex{ID: "a"}, cspf.Vertex{ID: "d"}, 0)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Call NearShortestPaths on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.NearShortestPaths(a, e, 20)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestPathsWithinCost(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 5), ShouldBeNil)
+		So(graph.AddEdge(b, e, 5), ShouldBeNil)
+		So(graph.AddEdge(a, c, 6), ShouldBeNil)
+		So(graph.AddEdge(c, e, 5), ShouldBeNil)
+		So(graph.AddEdge(a, d, 10), ShouldBeNil)
+		So(graph.AddEdge(d, e, 10), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+	})
+
+	Convey("List the paths under the threshold, cheapest first", t, func() {
+		paths, err := graph.PathsWithinCost(a, e, 11)
+		So(err, ShouldBeNil)
+		strs := []string{}
+		for _, path := range paths {
+			strs = append(strs, cspf.Path(path).String())
+		}
+		//A->B->C->E also costs 11, A->D->E costs 20
+		So(strs, ShouldResemble, []string{"A->B->E", "A->B->C->E", "A->C->E"})
+	})
+
+	Convey("A threshold below the optimum lists no path", t, func() {
+		paths, err := graph.PathsWithinCost(a, e, 9)
+		So(err, ShouldBeNil)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("Disabled edges are never traversed", t, func() {
+		diamond := generateDisabledDiamond()
+		paths, err := diamond.PathsWithinCost(cspf.Vertex{ID: "a"}, cspf.Vertex{ID: "d"}, 100)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("A large threshold lists all the paths", t, func() {
+		paths, err := graph.PathsWithinCost(a, e, 100)
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, len(graph.Paths(a, e)))
+		So(cspf.Path(paths[3]).String(), ShouldEqual, "A->D->E")
+	})
+
+	Convey("Call PathsWithinCost on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.PathsWithinCost(a, e, 10)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestPathsWithCumulativeCost(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 2, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, c, 2, tagRed), ShouldBeNil)
+		So(graph.AddEdge(c, e, 2, tagRed), ShouldBeNil)
+		So(graph.AddEdge(a, d, 1, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(d, e, 1, tagBlue), ShouldBeNil)
+	})
+
+	Convey("Every step of the CSPF path carries the running cost", t, func() {
+		cspfGraph, err := graph.CSPF(a, e, `link == "red"`)
+		So(err, ShouldBeNil)
+		paths := cspfGraph.PathsWithCumulativeCost(a, e)
+		So(len(paths), ShouldEqual, 1)
+		So(len(paths[0]), ShouldEqual, 3)
+		So(paths[0][0].Edge.To, ShouldResemble, b)
+		So(paths[0][0].CumCost, ShouldEqual, 2)
+		So(paths[0][1].Edge.To, ShouldResemble, c)
+		So(paths[0][1].CumCost, ShouldEqual, 4)
+		So(paths[0][2].Edge.To, ShouldResemble, e)
+		So(paths[0][2].CumCost, ShouldEqual, 6)
+	})
+
+	Convey("Call PathsWithCumulativeCost on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.PathsWithCumulativeCost(a, e), ShouldBeNil)
+	})
+}
+
+func TestIsSimplePath(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	ab := cspf.Edge{From: a, To: b, Cost: 1}
+	bc := cspf.Edge{From: b, To: c, Cost: 1}
+	ca := cspf.Edge{From: c, To: a, Cost: 1}
+	cd := cspf.Edge{From: c, To: d, Cost: 1}
+
+	Convey("A connected, loop-free sequence is simple", t, func() {
+		So(cspf.IsSimplePath([]cspf.Edge{ab, bc, cd}), ShouldBeTrue)
+		So(cspf.IsSimplePath([]cspf.Edge{ab}), ShouldBeTrue)
+		So(cspf.IsSimplePath(nil), ShouldBeTrue)
+	})
+
+	Convey("A disconnected sequence is not a path", t, func() {
+		So(cspf.IsSimplePath([]cspf.Edge{ab, cd}), ShouldBeFalse)
+	})
+
+	Convey("A sequence visiting a vertex twice is not simple", t, func() {
+		So(cspf.IsSimplePath([]cspf.Edge{ab, bc, ca}), ShouldBeFalse)
+		So(cspf.IsSimplePath([]cspf.Edge{{From: a, To: a, Cost: 1}}), ShouldBeFalse)
+	})
+
+	Convey("Listed paths are simple", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, a, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		for _, path := range graph.Paths(a, d) {
+			So(cspf.IsSimplePath(path), ShouldBeTrue)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package cspf
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Predicate is a constraint on the tags of an edge built out
+// of Go values rather than parsed from an expression string,
+// e.g. Or(Eq("link", "blue"), Eq("link", "redblue")).
+// CSPFPredicate selects the edges whose tags match it.
+type Predicate interface {
+	// Match reports whether the given tags satisfy the predicate.
+	Match(tags map[string]interface{}) (bool, error)
+}
+
+type eqPredicate struct {
+	key   string
+	value interface{}
+}
+
+type ltPredicate struct {
+	key   string
+	value interface{}
+}
+
+type notPredicate struct {
+	p Predicate
+}
+
+type andPredicate []Predicate
+
+type orPredicate []Predicate
+
+// Eq matches the edges with a tag with the given key and a
+// value equal to the given one. As in expressions, numbers are
+// compared by value whatever their type, so an int tag equals
+// the same float64 value. Other values must be deeply equal.
+func Eq(key string, value interface{}) Predicate {
+	return eqPredicate{key: key, value: value}
+}
+
+// Neq matches the edges that Eq would not match,
+// including the ones without the tag.
+func Neq(key string, value interface{}) Predicate {
+	return Not(Eq(key, value))
+}
+
+// Lt matches the edges with a tag with the given key and
+// a value less than the given one. Numbers are compared by
+// value and strings lexicographically. Values that cannot be
+// compared, such as a missing tag, make the query fail with
+// ErrNotNumeric.
+func Lt(key string, value interface{}) Predicate {
+	return ltPredicate{key: key, value: value}
+}
+
+// And matches the edges that all the given predicates match.
+// Predicates are evaluated in order, and the evaluation stops
+// at the first one that does not match.
+func And(predicates ...Predicate) Predicate {
+	return andPredicate(predicates)
+}
+
+// Or matches the edges that any of the given predicates match.
+// Predicates are evaluated in order, and the evaluation stops
+// at the first one that matches.
+func Or(predicates ...Predicate) Predicate {
+	return orPredicate(predicates)
+}
+
+// Not matches the edges the given predicate does not match.
+func Not(p Predicate) Predicate {
+	return notPredicate{p: p}
+}
+
+func (p eqPredicate) Match(tags map[string]interface{}) (bool, error) {
+	value, ok := tags[p.key]
+	if !ok {
+		return false, nil
+	}
+	if a, b, ok := numericPair(value, p.value); ok {
+		return a == b, nil
+	}
+	return reflect.DeepEqual(value, p.value), nil
+}
+
+func (p ltPredicate) Match(tags map[string]interface{}) (bool, error) {
+	value := tags[p.key]
+	if a, b, ok := numericPair(value, p.value); ok {
+		return a < b, nil
+	}
+	a, okA := value.(string)
+	b, okB := p.value.(string)
+	if okA && okB {
+		return a < b, nil
+	}
+	return false, fmt.Errorf("%w: %s %v (%T) < %v (%T)", ErrNotNumeric, p.key, value, value, p.value, p.value)
+}
+
+func (p notPredicate) Match(tags map[string]interface{}) (bool, error) {
+	match, err := p.p.Match(tags)
+	return !match, err
+}
+
+func (p andPredicate) Match(tags map[string]interface{}) (bool, error) {
+	for _, predicate := range p {
+		match, err := predicate.Match(tags)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (p orPredicate) Match(tags map[string]interface{}) (bool, error) {
+	for _, predicate := range p {
+		match, err := predicate.Match(tags)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+// numericPair converts two values to float64 if
+// they are both numbers, i.e. not strings.
+func numericPair(a, b interface{}) (float64, float64, bool) {
+	_, stringA := a.(string)
+	_, stringB := b.(string)
+	if stringA || stringB {
+		return 0, 0, false
+	}
+	x, okA := toFloat64(a)
+	y, okB := toFloat64(b)
+	return x, y, okA && okB
+}
+
+// CSPFPredicate is the same as CSPF, but the constraint
+// is the given predicate instead of an expression string.
+// The options that customize the expression language, such
+// as WithFunction, do not apply to predicates.
+func (g *Graph) CSPFPredicate(from, to Vertex, p Predicate, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	q.vertexTags = g.vertexTags
+	q.eval = func(c context.Context, parameter interface{}) (interface{}, error) {
+		tags, _ := parameter.(map[string]interface{})
+		return p.Match(tags)
+	}
+	return g.spf(from, to, q)
+}
+
+// CSPFEqual is the same as CSPF with the expression
+// key == value, but it compares the tags of every edge,
+// including the ones it inherits from its source vertex,
+// directly, with the semantics of Eq, bypassing the parsing
+// and the evaluation of an expression. It is the fast path
+// for the common constraint on a single exact tag value.
+func (g *Graph) CSPFEqual(from, to Vertex, key string, value interface{}) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	eq := eqPredicate{key: key, value: value}
+	q := newQuery(nil)
+	q.vertexTags = g.vertexTags
+	q.filters = append(q.filters, func(e Edge) bool {
+		match, _ := eq.Match(q.parameters(e))
+		return match
+	})
+	return g.spf(from, to, q)
+}
@@ -0,0 +1,118 @@
+package cspf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSPFPredicate(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+	tagRedBlue := cspf.Tag{
+		Key:   "link",
+		Value: "redblue",
+	}
+	latency := func(ms int) cspf.Tag {
+		return cspf.Tag{Key: "latency", Value: ms}
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue, latency(10)), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, tagRed, latency(1)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagBlue, latency(10)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, tagRed, latency(1)), ShouldBeNil)
+		So(graph.AddEdge(d, e, 1, tagRedBlue, latency(5)), ShouldBeNil)
+	})
+
+	Convey("A predicate gives the same result as the expression", t, func() {
+		p := cspf.Or(cspf.Eq("link", "blue"), cspf.Eq("link", "redblue"))
+		fromPredicate, err := graph.CSPFPredicate(a, e, p)
+		So(err, ShouldBeNil)
+		fromExpression, err := graph.CSPF(a, e, `link == "blue" || link == "redblue"`)
+		So(err, ShouldBeNil)
+		So(fromPredicate.Equal(fromExpression), ShouldBeTrue)
+		paths := fromPredicate.Paths(a, e)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d->e")
+	})
+
+	match := func(p cspf.Predicate, tags map[string]interface{}) bool {
+		m, err := p.Match(tags)
+		So(err, ShouldBeNil)
+		return m
+	}
+
+	Convey("Combine the predicates", t, func() {
+		p := cspf.And(cspf.Neq("link", "blue"), cspf.Not(cspf.Lt("latency", 2)))
+		So(match(p, map[string]interface{}{"link": "redblue", "latency": 5}), ShouldBeTrue)
+		So(match(p, map[string]interface{}{"link": "red", "latency": 1}), ShouldBeFalse)
+		So(match(p, map[string]interface{}{"link": "blue", "latency": 5}), ShouldBeFalse)
+
+		Convey("Numbers are compared by value", func() {
+			So(match(cspf.Eq("latency", 5.0), map[string]interface{}{"latency": 5}), ShouldBeTrue)
+			So(match(cspf.Lt("latency", 5), map[string]interface{}{"latency": 4.5}), ShouldBeTrue)
+		})
+
+		Convey("Values that cannot be compared are an error", func() {
+			_, err := cspf.Lt("latency", 2).Match(map[string]interface{}{})
+			So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+			_, err = graph.CSPFPredicate(a, e, cspf.Lt("link", 2))
+			So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+		})
+	})
+
+	Convey("Call CSPFPredicate on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.CSPFPredicate(a, e, cspf.Eq("link", "blue"))
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestCSPFEqual(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+	})
+
+	Convey("CSPFEqual gives the same result as the expression", t, func() {
+		fast, err := graph.CSPFEqual(a, d, "link", "blue")
+		So(err, ShouldBeNil)
+		parsed, err := graph.CSPF(a, d, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(fast.Equal(parsed), ShouldBeTrue)
+		So(cspf.Path(fast.Paths(a, d)[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Call CSPFEqual on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.CSPFEqual(a, d, "link", "blue")
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
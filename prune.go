@@ -0,0 +1,162 @@
+package cspf
+
+// PruneOptions selects the structures Prune removes.
+type PruneOptions struct {
+	// RemoveSelfLoops removes the edges that start and
+	// end at the same vertex, which can never be part
+	// of a shortest path.
+	RemoveSelfLoops bool
+	// RemoveIsolatedVertices removes the vertices with
+	// neither incoming nor outgoing edges.
+	RemoveIsolatedVertices bool
+	// RemoveDeadEnds removes the vertices with incoming
+	// but no outgoing edges, along with their incoming
+	// edges, except for the vertices listed in Sinks.
+	RemoveDeadEnds bool
+	// Sinks are the vertices kept by RemoveDeadEnds.
+	Sinks []Vertex
+}
+
+// PruneResult reports what Prune removed.
+type PruneResult struct {
+	// Number of self-loop edges removed.
+	SelfLoops int
+	// Number of isolated vertices removed.
+	IsolatedVertices int
+	// Number of dead-end vertices removed.
+	DeadEnds int
+}
+
+// Prune removes uninteresting structures from the graph.
+// Self-loops are removed first, then dead ends and then
+// isolated vertices, so a vertex left without edges by the
+// former steps is removed by the latter.
+// Dead ends are removed in a single pass: a vertex that
+// becomes a dead end because its successors were removed
+// is kept.
+func (g *Graph) Prune(opts PruneOptions) PruneResult {
+	result := PruneResult{}
+	if g == nil {
+		return result
+	}
+
+	if opts.RemoveSelfLoops {
+		for v, edges := range g.VertexSet {
+			kept := edges[:0]
+			for _, edge := range edges {
+				if edge.To == edge.From {
+					result.SelfLoops++
+					g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+					continue
+				}
+				kept = append(kept, edge)
+			}
+			g.VertexSet[v] = kept
+		}
+	}
+
+	if opts.RemoveDeadEnds {
+		sinks := make(map[Vertex]bool, len(opts.Sinks))
+		for _, v := range opts.Sinks {
+			sinks[v] = true
+		}
+		deadEnds := []Vertex{}
+		for v, edges := range g.VertexSet {
+			if len(edges) == 0 && !sinks[v] && g.hasIncomingEdges(v) {
+				deadEnds = append(deadEnds, v)
+			}
+		}
+		for _, v := range deadEnds {
+			g.removeVertex(v)
+		}
+		result.DeadEnds = len(deadEnds)
+	}
+
+	if opts.RemoveIsolatedVertices {
+		isolated := []Vertex{}
+		for v, edges := range g.VertexSet {
+			if len(edges) == 0 && !g.hasIncomingEdges(v) {
+				isolated = append(isolated, v)
+			}
+		}
+		for _, v := range isolated {
+			g.removeVertex(v)
+		}
+		result.IsolatedVertices = len(isolated)
+	}
+
+	return result
+}
+
+// hasIncomingEdges reports whether any edge ends at the given vertex.
+func (g *Graph) hasIncomingEdges(v Vertex) bool {
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.To == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeVertex removes a vertex from the graph, along with
+// all its incoming and outgoing edges and its default tags.
+func (g *Graph) removeVertex(v Vertex) {
+	for _, edge := range g.VertexSet[v] {
+		g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+	}
+	delete(g.VertexSet, v)
+	delete(g.vertexTags, v)
+	for u, edges := range g.VertexSet {
+		kept := edges[:0]
+		for _, edge := range edges {
+			if edge.To != v {
+				kept = append(kept, edge)
+				continue
+			}
+			g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+		}
+		g.VertexSet[u] = kept
+	}
+	g.record(Mutation{Kind: MutationRemoveVertex, Vertex: v})
+}
+
+// CollapseParallelEdges reduces every group of parallel edges,
+// i.e. edges with the same source and destination, to a single
+// edge, folding the group in insertion order through repeated
+// calls to combine: the first two edges are combined, then the
+// result with the third one, and so on. For instance, combine can
+// sum a capacity tag, or keep the edge with the smallest cost.
+// The combined edge keeps the source and destination of the group
+// whatever combine returns, and it takes the position of the first
+// edge of the group. Edges without parallels are left untouched.
+func (g *Graph) CollapseParallelEdges(combine func(a, b Edge) Edge) {
+	if g == nil {
+		return
+	}
+	for _, v := range g.Vertices() {
+		edges := g.VertexSet[v]
+		position := make(map[Vertex]int, len(edges))
+		collapsed := edges[:0]
+		//The first edge of every group, as it was
+		var first []Edge
+		for _, edge := range edges {
+			i, ok := position[edge.To]
+			if !ok {
+				position[edge.To] = len(collapsed)
+				collapsed = append(collapsed, edge)
+				first = append(first, edge)
+				continue
+			}
+			merged := combine(collapsed[i], edge)
+			merged.From, merged.To = edge.From, edge.To
+			collapsed[i] = merged
+			g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+		}
+		for i, edge := range collapsed {
+			g.recordUpdate(MutationUpdateEdge, first[i], edge)
+		}
+		g.VertexSet[v] = collapsed
+	}
+}
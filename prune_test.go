@@ -0,0 +1,129 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrune(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+	isolated := cspf.Vertex{ID: "isolated"}
+
+	//a -> b -> c, b -> d (dead end), self-loop on a,
+	//e is only connected by a self-loop and isolated
+	//has no edges at all
+	newGraph := func() *cspf.Graph {
+		graph := cspf.Graph{}
+		graph.AddEdge(a, b, 1)
+		graph.AddEdge(b, c, 1)
+		graph.AddEdge(b, d, 1)
+		graph.AddEdge(a, a, 0)
+		graph.AddEdge(e, e, 1)
+		graph.AddNode(isolated)
+		return &graph
+	}
+
+	Convey("Remove the self-loops", t, func() {
+		graph := newGraph()
+		result := graph.Prune(cspf.PruneOptions{RemoveSelfLoops: true})
+		So(result, ShouldResemble, cspf.PruneResult{SelfLoops: 2})
+		So(len(graph.VertexSet[a]), ShouldEqual, 1)
+		So(len(graph.VertexSet[e]), ShouldEqual, 0)
+		So(len(graph.VertexSet), ShouldEqual, 6)
+	})
+
+	Convey("Remove the isolated vertices", t, func() {
+		graph := newGraph()
+		result := graph.Prune(cspf.PruneOptions{RemoveIsolatedVertices: true})
+		So(result, ShouldResemble, cspf.PruneResult{IsolatedVertices: 1})
+		So(graph.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c, d, e})
+	})
+
+	Convey("Remove the dead ends except the sinks", t, func() {
+		graph := newGraph()
+		result := graph.Prune(cspf.PruneOptions{
+			RemoveDeadEnds: true,
+			Sinks:          []cspf.Vertex{c},
+		})
+		So(result, ShouldResemble, cspf.PruneResult{DeadEnds: 1})
+		So(graph.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c, e, isolated})
+		So(graph.PathStrings(b, c), ShouldResemble, []string{"b->c"})
+		So(len(graph.VertexSet[b]), ShouldEqual, 1)
+	})
+
+	Convey("Combine all the options", t, func() {
+		graph := newGraph()
+		result := graph.Prune(cspf.PruneOptions{
+			RemoveSelfLoops:        true,
+			RemoveIsolatedVertices: true,
+			RemoveDeadEnds:         true,
+			Sinks:                  []cspf.Vertex{c},
+		})
+		So(result, ShouldResemble, cspf.PruneResult{SelfLoops: 2, IsolatedVertices: 2, DeadEnds: 1})
+		So(graph.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c})
+	})
+
+	Convey("Call Prune on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Prune(cspf.PruneOptions{RemoveSelfLoops: true}), ShouldResemble, cspf.PruneResult{})
+	})
+}
+
+func TestCollapseParallelEdges(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+	sumCapacity := func(x, y cspf.Edge) cspf.Edge {
+		merged := cspf.Edge{Cost: x.Cost, Tags: map[string]interface{}{}}
+		if y.Cost < merged.Cost {
+			merged.Cost = y.Cost
+		}
+		merged.Tags["capacity"] = x.Tags["capacity"].(int) + y.Tags["capacity"].(int)
+		return merged
+	}
+
+	Convey("Populate the graph with parallel edges", t, func() {
+		So(graph.AddEdge(a, b, 2, cspf.Tag{Key: "capacity", Value: 10}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, cspf.Tag{Key: "capacity", Value: 1}), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "capacity", Value: 40}), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, cspf.Tag{Key: "capacity", Value: 5}), ShouldBeNil)
+	})
+
+	Convey("Parallel edges are summed into one", t, func() {
+		graph.CollapseParallelEdges(sumCapacity)
+		So(graph.VertexSet[a], ShouldResemble, []cspf.Edge{
+			{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"capacity": 50}},
+			{From: a, To: c, Cost: 1, Tags: map[string]interface{}{"capacity": 1}},
+		})
+		So(graph.VertexSet[b], ShouldResemble, []cspf.Edge{
+			{From: b, To: c, Cost: 1, Tags: map[string]interface{}{"capacity": 5}},
+		})
+	})
+
+	Convey("Groups are folded in insertion order", t, func() {
+		folded := cspf.Graph{}
+		for _, id := range []string{"x", "y", "z"} {
+			So(folded.AddEdgeWithID(id, a, b, 1), ShouldBeNil)
+		}
+		folded.CollapseParallelEdges(func(x, y cspf.Edge) cspf.Edge {
+			x.ID += y.ID
+			return x
+		})
+		So(len(folded.VertexSet[a]), ShouldEqual, 1)
+		So(folded.VertexSet[a][0].ID, ShouldEqual, "xyz")
+	})
+
+	Convey("Call CollapseParallelEdges on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(func() { nilGraph.CollapseParallelEdges(sumCapacity) }, ShouldNotPanic)
+	})
+}
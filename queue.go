@@ -0,0 +1,53 @@
+package cspf
+
+import "container/heap"
+
+// queueItem is a vertex waiting to be settled together
+// with its tentative distance from the source. The counter
+// is only used by searches on (vertex, counter) states.
+type queueItem struct {
+	vertex  Vertex
+	counter int
+	dist    uint64
+}
+
+// vertexQueue is a min-heap of vertices ordered by distance.
+// Equal distances are ordered by vertex ID, and then by
+// counter, so that every algorithm built on top of it is
+// deterministic.
+type vertexQueue []queueItem
+
+func (q vertexQueue) Len() int { return len(q) }
+
+func (q vertexQueue) Less(i, j int) bool {
+	if q[i].dist != q[j].dist {
+		return q[i].dist < q[j].dist
+	}
+	if q[i].vertex.ID != q[j].vertex.ID {
+		return q[i].vertex.ID < q[j].vertex.ID
+	}
+	return q[i].counter < q[j].counter
+}
+
+func (q vertexQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *vertexQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+
+func (q *vertexQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func (q *vertexQueue) push(v Vertex, dist uint64) {
+	heap.Push(q, queueItem{vertex: v, dist: dist})
+}
+
+func (q *vertexQueue) pushState(s searchState, dist uint64) {
+	heap.Push(q, queueItem{vertex: s.vertex, counter: s.counter, dist: dist})
+}
+
+func (q *vertexQueue) pop() queueItem {
+	return heap.Pop(q).(queueItem)
+}
@@ -0,0 +1,387 @@
+package cspf
+
+import "sort"
+
+// TransitiveClosure returns a graph with the same vertices and
+// a direct edge from every vertex to every other vertex it can
+// reach, whose cost is the shortest distance between the two.
+// Edges carry no tags, and a vertex is not linked to itself.
+func (g *Graph) TransitiveClosure() *Graph {
+	if g == nil {
+		return nil
+	}
+	closure := Graph{}
+	for _, v := range g.Vertices() {
+		closure.AddNode(v)
+		distSet, _ := g.distances(v, newQuery(nil))
+		reached := make([]Vertex, 0, len(distSet))
+		for w := range distSet {
+			if w != v {
+				reached = append(reached, w)
+			}
+		}
+		sortVertices(reached)
+		for _, w := range reached {
+			closure.addEdge(Edge{From: v, To: w, Cost: distSet[w]})
+		}
+	}
+	return &closure
+}
+
+// StronglyConnectedComponents partitions the vertices of the
+// graph into strongly connected components: two vertices are
+// in the same component if each one can reach the other through
+// enabled edges.
+// Vertices are sorted by ID within each component, and
+// components are sorted by the ID of their first vertex.
+func (g *Graph) StronglyConnectedComponents() [][]Vertex {
+	if g == nil {
+		return nil
+	}
+	labels, count := g.sccLabels()
+	components := make([][]Vertex, count)
+	for _, v := range g.Vertices() {
+		components[labels[v]] = append(components[labels[v]], v)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0].ID < components[j][0].ID
+	})
+	return components
+}
+
+// InSameSCC reports whether the two vertices belong to the
+// same strongly connected component, i.e. whether a can reach
+// b and b can reach a. A vertex is always in the same component
+// as itself, while vertices that are not part of the graph are
+// not in any component.
+func (g *Graph) InSameSCC(a, b Vertex) bool {
+	if g == nil {
+		return false
+	}
+	labels, _ := g.sccLabels()
+	labelA, okA := labels[a]
+	labelB, okB := labels[b]
+	return okA && okB && labelA == labelB
+}
+
+// sccLabels labels every vertex with the index of its strongly
+// connected component, and returns the number of components.
+// It implements the Tarjan algorithm with an explicit stack,
+// so that long paths cannot exhaust the goroutine stack.
+func (g *Graph) sccLabels() (map[Vertex]int, int) {
+	type frame struct {
+		vertex Vertex
+		next   int
+	}
+	index := make(map[Vertex]int, len(g.VertexSet))
+	lowLink := make(map[Vertex]int, len(g.VertexSet))
+	onStack := make(map[Vertex]bool)
+	labels := make(map[Vertex]int, len(g.VertexSet))
+	var stack []Vertex
+	count := 0
+
+	for _, root := range g.Vertices() {
+		if _, ok := index[root]; ok {
+			continue
+		}
+		index[root] = len(index)
+		lowLink[root] = index[root]
+		stack = append(stack, root)
+		onStack[root] = true
+		frames := []frame{{vertex: root}}
+
+		for len(frames) > 0 {
+			top := &frames[len(frames)-1]
+			v := top.vertex
+			if top.next < len(g.VertexSet[v]) {
+				edge := g.VertexSet[v][top.next]
+				top.next++
+				if edge.Disabled {
+					continue
+				}
+				w := edge.To
+				if _, visited := index[w]; !visited {
+					index[w] = len(index)
+					lowLink[w] = index[w]
+					stack = append(stack, w)
+					onStack[w] = true
+					frames = append(frames, frame{vertex: w})
+				} else if onStack[w] && index[w] < lowLink[v] {
+					lowLink[v] = index[w]
+				}
+				continue
+			}
+
+			//All the edges of v are explored: v is the root
+			//of a component if it cannot reach any vertex
+			//visited before it that is still on the stack
+			frames = frames[:len(frames)-1]
+			if lowLink[v] == index[v] {
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					labels[w] = count
+					if w == v {
+						break
+					}
+				}
+				count++
+			}
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].vertex
+				if lowLink[v] < lowLink[parent] {
+					lowLink[parent] = lowLink[v]
+				}
+			}
+		}
+	}
+	return labels, count
+}
+
+// IsDAG reports whether the graph is a directed acyclic graph,
+// i.e. no path over enabled edges leads from a vertex back to
+// itself. Self-loops are cycles. It is a cheap precondition
+// check for algorithms that require acyclic graphs.
+func (g *Graph) IsDAG() bool {
+	if g == nil {
+		return true
+	}
+	type frame struct {
+		vertex Vertex
+		next   int
+	}
+	//Vertices on the current DFS path are in progress,
+	//vertices whose descendants were all explored are done
+	const (
+		inProgress = 1
+		done       = 2
+	)
+	state := make(map[Vertex]int, len(g.VertexSet))
+	for _, root := range g.Vertices() {
+		if state[root] != 0 {
+			continue
+		}
+		state[root] = inProgress
+		frames := []frame{{vertex: root}}
+		for len(frames) > 0 {
+			top := &frames[len(frames)-1]
+			if top.next == len(g.VertexSet[top.vertex]) {
+				state[top.vertex] = done
+				frames = frames[:len(frames)-1]
+				continue
+			}
+			edge := g.VertexSet[top.vertex][top.next]
+			top.next++
+			if edge.Disabled {
+				continue
+			}
+			switch state[edge.To] {
+			case inProgress:
+				return false
+			case 0:
+				state[edge.To] = inProgress
+				frames = append(frames, frame{vertex: edge.To})
+			}
+		}
+	}
+	return true
+}
+
+// CutVertices returns the vertices that lie on every path
+// from one vertex to another, i.e. the vertices other than
+// the two ends whose removal disconnects them, sorted by ID.
+// They are the single points of failure between the two.
+// Only enabled edges are considered, like SPF does. The list
+// is empty if the destination cannot be reached.
+func (g *Graph) CutVertices(from, to Vertex) []Vertex {
+	if g == nil {
+		return nil
+	}
+	cuts := []Vertex{}
+	forward := g.reachable(from, nil)
+	if !forward[to] || from == to {
+		return cuts
+	}
+	//Only the vertices on some path are candidates
+	backward := g.transpose().reachable(to, nil)
+	for _, v := range g.Vertices() {
+		if v == from || v == to || !forward[v] || !backward[v] {
+			continue
+		}
+		if !g.reachable(from, &v)[to] {
+			cuts = append(cuts, v)
+		}
+	}
+	return cuts
+}
+
+// reachable returns the set of vertices that can be reached
+// from the given one through enabled edges, without traversing
+// the skipped vertex if not nil.
+func (g *Graph) reachable(from Vertex, skip *Vertex) map[Vertex]bool {
+	reached := map[Vertex]bool{from: true}
+	pending := []Vertex{from}
+	for len(pending) > 0 {
+		v := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, edge := range g.VertexSet[v] {
+			if edge.Disabled || reached[edge.To] || (skip != nil && edge.To == *skip) {
+				continue
+			}
+			reached[edge.To] = true
+			pending = append(pending, edge.To)
+		}
+	}
+	return reached
+}
+
+// ArticulationPoints returns the vertices whose removal would
+// split the connected component they belong to, sorted by ID:
+// the single points of failure of the graph.
+// The graph is treated as undirected: edges connect their two
+// ends both ways, and parallel edges and self-loops are ignored.
+// Only enabled edges are considered, like CutVertices does.
+func (g *Graph) ArticulationPoints() []Vertex {
+	if g == nil {
+		return nil
+	}
+	vertices, adjacency := g.undirectedAdjacency()
+	points := []Vertex{}
+	articulation, _ := lowLink(adjacency)
+	for i, v := range vertices {
+		if articulation[i] {
+			points = append(points, v)
+		}
+	}
+	return points
+}
+
+// Bridges returns the edges whose removal would split the
+// connected component they belong to, sorted like CanonicalEdges.
+// The graph is treated as undirected, like ArticulationPoints
+// does: a link between two vertices is a bridge if no other path
+// connects them, and all the enabled edges of the graph between
+// the two, in either direction, are listed for it.
+func (g *Graph) Bridges() []Edge {
+	if g == nil {
+		return nil
+	}
+	vertices, adjacency := g.undirectedAdjacency()
+	_, bridges := lowLink(adjacency)
+	isBridge := make(map[[2]Vertex]bool, len(bridges))
+	for _, bridge := range bridges {
+		u, w := vertices[bridge[0]], vertices[bridge[1]]
+		isBridge[[2]Vertex{u, w}] = true
+		isBridge[[2]Vertex{w, u}] = true
+	}
+	edges := []Edge{}
+	for _, edge := range g.CanonicalEdges() {
+		if !edge.Disabled && isBridge[[2]Vertex{edge.From, edge.To}] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// undirectedAdjacency returns the vertices of the graph sorted
+// by ID and, for every vertex, the sorted indices of the vertices
+// it is linked to by an enabled edge in either direction.
+// Self-loops are left out and parallel links are merged.
+func (g *Graph) undirectedAdjacency() ([]Vertex, [][]int) {
+	vertices := g.Vertices()
+	index := make(map[Vertex]int, len(vertices))
+	for i, v := range vertices {
+		index[v] = i
+	}
+	linked := make([]map[int]bool, len(vertices))
+	for i := range linked {
+		linked[i] = make(map[int]bool)
+	}
+	for _, v := range vertices {
+		for _, edge := range g.VertexSet[v] {
+			u, w := index[edge.From], index[edge.To]
+			if u != w && !edge.Disabled {
+				linked[u][w] = true
+				linked[w][u] = true
+			}
+		}
+	}
+	adjacency := make([][]int, len(vertices))
+	for i, neighbors := range linked {
+		for w := range neighbors {
+			adjacency[i] = append(adjacency[i], w)
+		}
+		sort.Ints(adjacency[i])
+	}
+	return vertices, adjacency
+}
+
+// lowLink runs an iterative Depth-First Search on an undirected
+// simple graph, computing the low-link value of every vertex, i.e.
+// the earliest discovered vertex its subtree links back to. It
+// returns which vertices are articulation points and the bridges,
+// as pairs of vertex indices.
+func lowLink(adjacency [][]int) ([]bool, [][2]int) {
+	n := len(adjacency)
+	discovery := make([]int, n)
+	low := make([]int, n)
+	parent := make([]int, n)
+	for i := range discovery {
+		discovery[i] = -1
+	}
+	articulation := make([]bool, n)
+	var bridges [][2]int
+	type frame struct {
+		vertex int
+		next   int
+	}
+	time := 0
+	for root := range adjacency {
+		if discovery[root] != -1 {
+			continue
+		}
+		discovery[root], low[root], parent[root] = time, time, -1
+		time++
+		rootChildren := 0
+		stack := []frame{{vertex: root}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			v := top.vertex
+			if top.next < len(adjacency[v]) {
+				w := adjacency[v][top.next]
+				top.next++
+				switch {
+				case discovery[w] == -1:
+					discovery[w], low[w], parent[w] = time, time, v
+					time++
+					if v == root {
+						rootChildren++
+					}
+					stack = append(stack, frame{vertex: w})
+				case w != parent[v] && discovery[w] < low[v]:
+					low[v] = discovery[w]
+				}
+				continue
+			}
+			//The subtree of v is complete, report
+			//its low-link to the parent
+			stack = stack[:len(stack)-1]
+			p := parent[v]
+			if p == -1 {
+				continue
+			}
+			if low[v] < low[p] {
+				low[p] = low[v]
+			}
+			if low[v] > discovery[p] {
+				bridges = append(bridges, [2]int{p, v})
+			}
+			if p != root && low[v] >= discovery[p] {
+				articulation[p] = true
+			}
+		}
+		articulation[root] = rootChildren > 1
+	}
+	return articulation, bridges
+}
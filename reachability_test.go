@@ -0,0 +1,251 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransitiveClosure(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a chain graph", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 2), ShouldBeNil)
+		So(graph.AddEdge(c, d, 3), ShouldBeNil)
+		So(graph.AddEdge(d, e, 4), ShouldBeNil)
+	})
+
+	Convey("Every reachable pair gets a direct edge", t, func() {
+		closure := graph.TransitiveClosure()
+		So(len(closure.VertexSet), ShouldEqual, 5)
+		So(len(closure.VertexSet[a]), ShouldEqual, 4)
+		So(closure.VertexSet[a][3], ShouldResemble, cspf.Edge{From: a, To: e, Cost: 10})
+		So(closure.VertexSet[d], ShouldResemble, []cspf.Edge{{From: d, To: e, Cost: 4}})
+		//Nothing is reachable from the end of the chain
+		So(closure.VertexSet[e], ShouldBeEmpty)
+	})
+
+	Convey("Call TransitiveClosure on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.TransitiveClosure(), ShouldBeNil)
+	})
+}
+
+func TestInSameSCC(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a cyclic graph", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, a, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		//E is reachable, but it cannot get back
+		So(graph.AddEdge(d, e, 1), ShouldBeNil)
+	})
+
+	Convey("Label the strongly connected components", t, func() {
+		So(graph.StronglyConnectedComponents(), ShouldResemble, [][]cspf.Vertex{{a, b, c, d}, {e}})
+	})
+
+	Convey("Vertices of the cycle are in the same component", t, func() {
+		So(graph.InSameSCC(a, c), ShouldBeTrue)
+		So(graph.InSameSCC(d, b), ShouldBeTrue)
+		So(graph.InSameSCC(e, e), ShouldBeTrue)
+	})
+
+	Convey("E is in a component of its own", t, func() {
+		So(graph.InSameSCC(a, e), ShouldBeFalse)
+		So(graph.InSameSCC(e, d), ShouldBeFalse)
+		So(graph.InSameSCC(a, cspf.Vertex{ID: "F"}), ShouldBeFalse)
+	})
+
+	Convey("Disabled edges do not join components", t, func() {
+		pair := cspf.Graph{}
+		So(pair.AddEdge(a, b, 1), ShouldBeNil)
+		So(pair.AddEdge(b, a, 1), ShouldBeNil)
+		So(pair.InSameSCC(a, b), ShouldBeTrue)
+		So(pair.IsStronglyConnected(), ShouldBeTrue)
+		So(pair.SetEdgeEnabled(b, a, false), ShouldEqual, 1)
+		So(pair.InSameSCC(a, b), ShouldBeFalse)
+		So(pair.IsStronglyConnected(), ShouldBeFalse)
+		So(pair.StronglyConnectedComponents(), ShouldResemble, [][]cspf.Vertex{{a}, {b}})
+	})
+
+	Convey("Call InSameSCC on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.InSameSCC(a, b), ShouldBeFalse)
+		So(nilGraph.StronglyConnectedComponents(), ShouldBeNil)
+	})
+}
+
+func TestIsDAG(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	Convey("The diamond graph is acyclic", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.IsDAG(), ShouldBeTrue)
+
+		Convey("Closing a cycle makes it cyclic", func() {
+			So(graph.AddEdge(d, a, 1), ShouldBeNil)
+			So(graph.IsDAG(), ShouldBeFalse)
+		})
+
+		Convey("Disabled edges do not close cycles", func() {
+			So(graph.AddEdge(d, a, 1), ShouldBeNil)
+			So(graph.SetEdgeEnabled(d, a, false), ShouldEqual, 1)
+			So(graph.IsDAG(), ShouldBeTrue)
+		})
+	})
+
+	Convey("A self-loop is a cycle", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, a, 1), ShouldBeNil)
+		So(graph.IsDAG(), ShouldBeFalse)
+	})
+
+	Convey("Call IsDAG on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.IsDAG(), ShouldBeTrue)
+	})
+}
+
+func TestCutVertices(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	f := cspf.Vertex{ID: "F"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a graph with a mandatory intermediate vertex", t, func() {
+		//Two branches from A join at D, the only way to F
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, e, 1), ShouldBeNil)
+		So(graph.AddEdge(e, f, 1), ShouldBeNil)
+		So(graph.AddEdge(d, f, 10), ShouldBeNil)
+	})
+
+	Convey("Only D lies on every path from A to F", t, func() {
+		So(graph.CutVertices(a, f), ShouldResemble, []cspf.Vertex{d})
+	})
+
+	Convey("Every intermediate vertex of a chain is a cut vertex", t, func() {
+		So(graph.CutVertices(b, f), ShouldResemble, []cspf.Vertex{d})
+		So(graph.CutVertices(d, e), ShouldBeEmpty)
+		So(graph.CutVertices(b, e), ShouldResemble, []cspf.Vertex{d})
+	})
+
+	Convey("Unreachable destinations have no cut vertices", t, func() {
+		So(graph.CutVertices(f, a), ShouldBeEmpty)
+	})
+
+	Convey("Disabled edges do not make alternative paths", t, func() {
+		a := cspf.Vertex{ID: "a"}
+		c := cspf.Vertex{ID: "c"}
+		d := cspf.Vertex{ID: "d"}
+		So(generateDisabledDiamond().CutVertices(a, d), ShouldResemble, []cspf.Vertex{c})
+	})
+
+	Convey("Call CutVertices on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.CutVertices(a, f), ShouldBeNil)
+	})
+}
+
+func TestArticulationPointsAndBridges(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	e := cspf.Vertex{ID: "E"}
+	f := cspf.Vertex{ID: "F"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a barbell: two triangles joined by one link", t, func() {
+		for _, link := range [][2]cspf.Vertex{{a, b}, {b, c}, {c, a}, {d, e}, {e, f}, {f, d}} {
+			So(graph.AddMixedEdge(link[0], link[1], 1, true), ShouldBeNil)
+		}
+		So(graph.AddMixedEdge(c, d, 5, true), ShouldBeNil)
+		//A parallel edge does not make the link redundant
+		So(graph.AddEdge(c, d, 7), ShouldBeNil)
+	})
+
+	Convey("The ends of the link are the articulation points", t, func() {
+		So(graph.ArticulationPoints(), ShouldResemble, []cspf.Vertex{c, d})
+	})
+
+	Convey("The link is the only bridge", t, func() {
+		So(graph.Bridges(), ShouldResemble, []cspf.Edge{
+			{From: c, To: d, Cost: 5},
+			{From: c, To: d, Cost: 7},
+			{From: d, To: c, Cost: 5},
+		})
+	})
+
+	Convey("A second link removes the bridge", t, func() {
+		redundant := cspf.Graph{}
+		for _, v := range graph.Vertices() {
+			for _, edge := range graph.VertexSet[v] {
+				So(redundant.AddEdge(edge.From, edge.To, edge.Cost), ShouldBeNil)
+			}
+		}
+		So(redundant.AddEdge(a, f, 1), ShouldBeNil)
+		So(redundant.Bridges(), ShouldBeEmpty)
+		So(redundant.ArticulationPoints(), ShouldBeEmpty)
+
+		Convey("Unless the second link is disabled", func() {
+			So(redundant.SetEdgeEnabled(a, f, false), ShouldEqual, 1)
+			So(redundant.ArticulationPoints(), ShouldResemble, []cspf.Vertex{c, d})
+			So(redundant.Bridges(), ShouldResemble, []cspf.Edge{
+				{From: c, To: d, Cost: 5},
+				{From: c, To: d, Cost: 7},
+				{From: d, To: c, Cost: 5},
+			})
+		})
+	})
+
+	Convey("Every inner vertex of a chain is an articulation point", t, func() {
+		chain := cspf.Graph{}
+		So(chain.AddEdge(a, b, 1), ShouldBeNil)
+		So(chain.AddEdge(c, b, 1), ShouldBeNil)
+		So(chain.AddEdge(c, d, 1), ShouldBeNil)
+		So(chain.AddEdge(d, d, 1), ShouldBeNil)
+		So(chain.ArticulationPoints(), ShouldResemble, []cspf.Vertex{b, c})
+		So(len(chain.Bridges()), ShouldEqual, 3)
+	})
+
+	Convey("Call them on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.ArticulationPoints(), ShouldBeNil)
+		So(nilGraph.Bridges(), ShouldBeNil)
+	})
+}
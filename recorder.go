@@ -0,0 +1,90 @@
+package cspf
+
+import "time"
+
+// recordedQuery is a query issued through a QueryRecorder.
+type recordedQuery struct {
+	from, to Vertex
+	// Constraint expression, only for CSPF queries.
+	exp         string
+	constrained bool
+	opts        []Option
+}
+
+// run issues the query on the given graph.
+func (rq recordedQuery) run(g *Graph) (*Graph, error) {
+	if rq.constrained {
+		return g.CSPF(rq.from, rq.to, rq.exp, rq.opts...)
+	}
+	return g.SPF(rq.from, rq.to, rq.opts...)
+}
+
+// QueryRecorder wraps a graph and records the SPF and CSPF
+// queries issued through it, so that the same workload can be
+// replayed later, e.g. to benchmark a different implementation
+// or to detect performance regressions.
+type QueryRecorder struct {
+	graph   *Graph
+	queries []recordedQuery
+}
+
+// NewQueryRecorder returns a recorder that issues
+// its queries on the given graph.
+func NewQueryRecorder(g *Graph) *QueryRecorder {
+	return &QueryRecorder{graph: g}
+}
+
+// SPF records the query and runs it on the wrapped graph.
+func (r *QueryRecorder) SPF(from, to Vertex, opts ...Option) (*Graph, error) {
+	rq := recordedQuery{from: from, to: to, opts: opts}
+	r.queries = append(r.queries, rq)
+	return rq.run(r.graph)
+}
+
+// CSPF records the query and runs it on the wrapped graph.
+func (r *QueryRecorder) CSPF(from, to Vertex, exp string, opts ...Option) (*Graph, error) {
+	rq := recordedQuery{from: from, to: to, exp: exp, constrained: true, opts: opts}
+	r.queries = append(r.queries, rq)
+	return rq.run(r.graph)
+}
+
+// Len returns the number of queries recorded so far.
+func (r *QueryRecorder) Len() int {
+	return len(r.queries)
+}
+
+// QueryResult is the outcome of a replayed query.
+type QueryResult struct {
+	// Graph is the result graph of the query.
+	Graph *Graph
+	// Err is the error returned by the query, if any.
+	Err error
+	// Duration is the time the query took.
+	Duration time.Duration
+}
+
+// ReplayReport lists the outcome of every replayed query,
+// in the order they were recorded, with their total time.
+type ReplayReport struct {
+	// Results of the queries.
+	Results []QueryResult
+	// Total time taken by all the queries.
+	Total time.Duration
+}
+
+// Replay issues all the recorded queries, with the same
+// arguments and options, on the given graph, which can
+// differ from the one the queries were recorded on.
+// Queries that fail do not stop the replay: their
+// errors are reported in the results.
+func (r *QueryRecorder) Replay(g *Graph) ReplayReport {
+	report := ReplayReport{Results: make([]QueryResult, 0, len(r.queries))}
+	for _, rq := range r.queries {
+		start := time.Now()
+		result, err := rq.run(g)
+		elapsed := time.Since(start)
+		report.Results = append(report.Results, QueryResult{Graph: result, Err: err, Duration: elapsed})
+		report.Total += elapsed
+	}
+	return report
+}
@@ -0,0 +1,63 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQueryRecorder(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	graph := cspf.Graph{}
+	recorder := cspf.NewQueryRecorder(&graph)
+	var recorded []*cspf.Graph
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, tagRed), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, tagRed), ShouldBeNil)
+	})
+
+	Convey("Record a few queries", t, func() {
+		spf, err := recorder.SPF(a, d)
+		So(err, ShouldBeNil)
+		cspfGraph, err := recorder.CSPF(a, d, `link == "red"`)
+		So(err, ShouldBeNil)
+		single, err := recorder.SPF(b, d, cspf.WithSinglePath())
+		So(err, ShouldBeNil)
+		_, err = recorder.CSPF(a, d, `link ==`)
+		So(err, ShouldNotBeNil)
+		recorded = []*cspf.Graph{spf, cspfGraph, single, nil}
+		So(recorder.Len(), ShouldEqual, 4)
+	})
+
+	Convey("Replay reproduces the same results", t, func() {
+		report := recorder.Replay(&graph)
+		So(len(report.Results), ShouldEqual, 4)
+		for i, result := range report.Results[:3] {
+			So(result.Err, ShouldBeNil)
+			So(result.Graph.Equal(recorded[i]), ShouldBeTrue)
+			So(report.Total, ShouldBeGreaterThanOrEqualTo, result.Duration)
+		}
+		So(report.Results[3].Err, ShouldNotBeNil)
+	})
+
+	Convey("Replay on another graph", t, func() {
+		other := cspf.Graph{}
+		So(other.AddEdge(a, d, 1), ShouldBeNil)
+		report := recorder.Replay(&other)
+		So(len(report.Results[0].Graph.Paths(a, d)), ShouldEqual, 1)
+		So(cspf.Path(report.Results[0].Graph.Paths(a, d)[0]).String(), ShouldEqual, "a->d")
+	})
+}
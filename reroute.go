@@ -0,0 +1,206 @@
+package cspf
+
+import "reflect"
+
+// RerouteAvoiding runs the SPF algorithm looking for an
+// alternate route that avoids the given edges, e.g. the
+// edges of a primary path that failed.
+// By default, the edges to avoid are excluded from the
+// search ("hard avoid"), so the result graph contains none
+// of them and it is empty if no other route exists.
+// With the WithAvoidPenalty option, the edges to avoid are
+// kept but their cost is increased by the penalty ("soft
+// penalize"), so the result prefers routes sharing as few
+// of them as possible, but can still use them as a last resort.
+// A zero penalty excludes them like no option does.
+// Edges are matched by source, destination, cost and tags.
+func (g *Graph) RerouteAvoiding(from, to Vertex, avoid []Edge, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	if q.avoidPenalty == 0 {
+		q.filters = append(q.filters, func(e Edge) bool {
+			return !containsEdge(avoid, e)
+		})
+	} else {
+		q.costFunc = func(e Edge) uint64 {
+			if containsEdge(avoid, e) {
+				return addCost(e.Cost, q.avoidPenalty)
+			}
+			return e.Cost
+		}
+	}
+	return g.spf(from, to, q)
+}
+
+// SPFWithTagBias runs the SPF algorithm as if the edges carrying
+// a tag with the given key and a value deeply equal to the given
+// one cost delta more, e.g. to steer traffic away from links under
+// maintenance for a single query. The graph is not modified, and
+// the edges of the result graph keep their own cost.
+func (g *Graph) SPFWithTagBias(from, to Vertex, key string, value interface{}, delta uint64) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(nil)
+	q.costFunc = func(e Edge) uint64 {
+		if e.hasTag(key, value) {
+			return addCost(e.Cost, delta)
+		}
+		return e.Cost
+	}
+	return g.spf(from, to, q)
+}
+
+// SPFAvoidingEdge runs the SPF algorithm excluding the given
+// edge from the search, e.g. to simulate the failure of a link
+// without modifying the graph. It is the same as RerouteAvoiding
+// with a single edge to avoid.
+func (g *Graph) SPFAvoidingEdge(from, to Vertex, avoid Edge) (*Graph, error) {
+	return g.RerouteAvoiding(from, to, []Edge{avoid})
+}
+
+// SPFActive runs the SPF algorithm only traversing the edges
+// for which isActive returns true, e.g. to consult an external
+// record of the links that are currently up without modifying
+// the graph. The callback is consulted live, every time the
+// search considers an edge, and a nil callback makes every edge
+// active. Disabled edges are never traversed.
+func (g *Graph) SPFActive(from, to Vertex, isActive func(Edge) bool, opts ...Option) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(opts)
+	if isActive != nil {
+		q.filters = append(q.filters, isActive)
+	}
+	return g.spf(from, to, q)
+}
+
+// SPFInLayer runs the SPF algorithm only traversing the edges of
+// a single layer of the graph, i.e. the edges carrying a tag with
+// the key layerTag and a value deeply equal to layer, e.g. to route
+// within the MPLS layer of a graph with parallel MPLS and IP edges.
+// It is the same as CSPF with an equality constraint, without
+// parsing an expression: edges inherit the tag from the default
+// tags of their source vertex, and edges without the tag belong
+// to no layer.
+func (g *Graph) SPFInLayer(from, to Vertex, layerTag string, layer interface{}) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	q := newQuery(nil)
+	q.vertexTags = g.vertexTags
+	q.filters = append(q.filters, func(e Edge) bool {
+		value, ok := q.parameters(e)[layerTag]
+		return ok && reflect.DeepEqual(value, layer)
+	})
+	return g.spf(from, to, q)
+}
+
+// SPFAffected returns the vertices whose shortest distance from
+// the given vertex may change when an edge of the graph changes,
+// sorted by ID, so that a controller can limit the recomputation
+// of its routes to them after a localized change.
+// changedEdge is the edge as it is going to be, and it is matched
+// to the current edges of the graph by source, destination and ID:
+//   - if it costs more than the cheapest enabled matching edge, or
+//     it is disabled, e.g. after a failure, the affected vertices
+//     are the ones downstream of the matching edge in the graph SPF
+//     returns for the source, if the edge is part of it: its
+//     destination and all the vertices whose shortest paths go
+//     through it, some of which may keep their distance thanks to
+//     equal-cost alternates;
+//   - if it costs less, or it is a new edge, the affected vertices
+//     are the ones that get closer to the source through it.
+//
+// The source itself is never affected.
+func (g *Graph) SPFAffected(from Vertex, changedEdge Edge) ([]Vertex, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+	oldCost := infinity
+	for _, edge := range g.VertexSet[changedEdge.From] {
+		if edge.To == changedEdge.To && edge.ID == changedEdge.ID && !edge.Disabled && edge.Cost < oldCost {
+			oldCost = edge.Cost
+		}
+	}
+	newCost := changedEdge.Cost
+	if changedEdge.Disabled {
+		newCost = infinity
+	}
+
+	affected := []Vertex{}
+	fromDist, ok := distSet[changedEdge.From]
+	if !ok || newCost == oldCost || changedEdge.From == changedEdge.To {
+		return affected, nil
+	}
+	if newCost > oldCost {
+		//Only a tight edge of the shortest-path graph matters,
+		//and then everything downstream of it is affected
+		if addCost(fromDist, oldCost) != distSet[changedEdge.To] {
+			return affected, nil
+		}
+		successors := make(map[Vertex][]Vertex)
+		for v, edges := range prevSet {
+			for _, edge := range edges {
+				successors[edge.From] = append(successors[edge.From], v)
+			}
+		}
+		visited := map[Vertex]bool{changedEdge.To: true}
+		pending := []Vertex{changedEdge.To}
+		for len(pending) > 0 {
+			v := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if v != from {
+				affected = append(affected, v)
+			}
+			for _, next := range successors[v] {
+				if !visited[next] {
+					visited[next] = true
+					pending = append(pending, next)
+				}
+			}
+		}
+	} else {
+		//Search from the destination of the edge, only
+		//following the vertices that get closer
+		improved := make(map[Vertex]uint64)
+		queue := vertexQueue{}
+		dist := addCost(fromDist, newCost)
+		if current, ok := distSet[changedEdge.To]; dist != infinity && (!ok || dist < current) {
+			improved[changedEdge.To] = dist
+			queue.push(changedEdge.To, dist)
+		}
+		settled := make(map[Vertex]bool)
+		for queue.Len() > 0 {
+			item := queue.pop()
+			if settled[item.vertex] {
+				continue
+			}
+			settled[item.vertex] = true
+			affected = append(affected, item.vertex)
+			for _, edge := range g.VertexSet[item.vertex] {
+				if edge.Disabled || settled[edge.To] {
+					continue
+				}
+				dist := addCost(item.dist, edge.Cost)
+				current, reached := distSet[edge.To]
+				if best, ok := improved[edge.To]; ok {
+					current, reached = best, true
+				}
+				if dist != infinity && (!reached || dist < current) {
+					improved[edge.To] = dist
+					queue.push(edge.To, dist)
+				}
+			}
+		}
+	}
+	sortVertices(affected)
+	return affected, nil
+}
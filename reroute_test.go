@@ -0,0 +1,317 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRerouteAvoiding(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 3), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+	})
+
+	primary := []cspf.Edge{}
+
+	Convey("Find the primary path", t, func() {
+		spfGraph, err := graph.SPF(a, d)
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d")
+		primary = paths[0]
+	})
+
+	Convey("Reroute around the failed primary path", t, func() {
+		spfGraph, err := graph.RerouteAvoiding(a, d, primary)
+		So(err, ShouldBeNil)
+		paths := spfGraph.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Reroute around a single failed edge", t, func() {
+		spfGraph, err := graph.RerouteAvoiding(a, d, primary[1:])
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->c->d"})
+	})
+
+	Convey("Penalize the failed edges instead of excluding them", t, func() {
+		//Sharing the first edge costs less than the penalty
+		spfGraph, err := graph.RerouteAvoiding(a, d, primary[1:], cspf.WithAvoidPenalty(10))
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, d), ShouldResemble, []string{"a->b->c->d"})
+		//With no alternative, the penalized edges are still used
+		spfGraph, err = graph.RerouteAvoiding(a, b, primary, cspf.WithAvoidPenalty(10))
+		So(err, ShouldBeNil)
+		So(spfGraph.PathStrings(a, b), ShouldResemble, []string{"a->b"})
+	})
+
+	Convey("Hard avoid leaves no route if there is no alternative", t, func() {
+		spfGraph, err := graph.RerouteAvoiding(a, b, primary)
+		So(err, ShouldBeNil)
+		So(len(spfGraph.Paths(a, b)), ShouldEqual, 0)
+		//So does a zero penalty
+		spfGraph, err = graph.RerouteAvoiding(a, b, primary, cspf.WithAvoidPenalty(0))
+		So(err, ShouldBeNil)
+		So(len(spfGraph.Paths(a, b)), ShouldEqual, 0)
+	})
+
+	Convey("Call RerouteAvoiding on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.RerouteAvoiding(a, d, primary)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFWithTagBias(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	maintenance := cspf.Tag{
+		Key:   "maintenance",
+		Value: true,
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, maintenance), ShouldBeNil)
+		So(graph.AddEdge(a, c, 5), ShouldBeNil)
+		So(graph.AddEdge(c, d, 5), ShouldBeNil)
+	})
+
+	Convey("The bias reroutes around maintenance links", t, func() {
+		spf, err := graph.SPFWithTagBias(a, d, "maintenance", true, 50)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		//The graph keeps the original costs
+		So(graph.VertexSet[b][0].Cost, ShouldEqual, 1)
+	})
+
+	Convey("A small bias keeps the maintenance links", t, func() {
+		spf, err := graph.SPFWithTagBias(a, d, "maintenance", true, 5)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->d")
+	})
+
+	Convey("Call SPFWithTagBias on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFWithTagBias(a, d, "maintenance", true, 50)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFAvoidingEdge(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with a primary and a backup path", t, func() {
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+	})
+
+	Convey("Avoiding the primary link yields the backup path", t, func() {
+		spf, err := graph.SPFAvoidingEdge(a, c, cspf.Edge{From: a, To: c, Cost: 1})
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, c)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->c")
+		//The graph is not modified
+		So(len(graph.VertexSet[a]), ShouldEqual, 2)
+	})
+
+	Convey("Call SPFAvoidingEdge on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFAvoidingEdge(a, c, cspf.Edge{From: a, To: c, Cost: 1})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFActive(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+	active := map[string]bool{}
+	isActive := func(e cspf.Edge) bool {
+		return active[e.ID]
+	}
+
+	Convey("Populate the graph with a primary and a backup path", t, func() {
+		So(graph.AddEdgeWithID("primary", a, c, 1), ShouldBeNil)
+		So(graph.AddEdgeWithID("backup-1", a, b, 1), ShouldBeNil)
+		So(graph.AddEdgeWithID("backup-2", b, c, 1), ShouldBeNil)
+		active["primary"], active["backup-1"], active["backup-2"] = true, true, true
+	})
+
+	Convey("All the edges are active", t, func() {
+		spf, err := graph.SPFActive(a, c, isActive)
+		So(err, ShouldBeNil)
+		So(cspf.Path(spf.Paths(a, c)[0]).String(), ShouldEqual, "a->c")
+	})
+
+	Convey("Deactivating the primary link reroutes the path", t, func() {
+		active["primary"] = false
+		spf, err := graph.SPFActive(a, c, isActive)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, c)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->b->c")
+	})
+
+	Convey("Deactivating the backup too makes the target unreachable", t, func() {
+		active["backup-2"] = false
+		spf, err := graph.SPFActive(a, c, isActive)
+		So(err, ShouldBeNil)
+		So(spf.Paths(a, c), ShouldBeEmpty)
+		_, found := spf.VertexSet[c]
+		So(found, ShouldBeFalse)
+	})
+
+	Convey("Reactivating the primary link restores it", t, func() {
+		active["primary"] = true
+		spf, err := graph.SPFActive(a, c, isActive)
+		So(err, ShouldBeNil)
+		So(cspf.Path(spf.Paths(a, c)[0]).String(), ShouldEqual, "a->c")
+	})
+
+	Convey("Call SPFActive on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFActive(a, c, isActive)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFInLayer(t *testing.T) {
+	mpls := cspf.Tag{Key: "layer", Value: "mpls"}
+	ip := cspf.Tag{Key: "layer", Value: "ip"}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate two layers with parallel edges", t, func() {
+		So(graph.AddEdge(a, b, 1, mpls), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, mpls), ShouldBeNil)
+		So(graph.AddEdge(a, b, 5, ip), ShouldBeNil)
+		So(graph.AddEdge(b, d, 5, ip), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, ip), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, ip), ShouldBeNil)
+		//An edge of no layer
+		So(graph.AddEdge(a, d, 1), ShouldBeNil)
+	})
+
+	Convey("Every layer has its own shortest path", t, func() {
+		spf, err := graph.SPFInLayer(a, d, "layer", "mpls")
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+		So(cspf.Path(spf.Paths(a, d)[0]).Cost(), ShouldEqual, 2)
+
+		spf, err = graph.SPFInLayer(a, d, "layer", "ip")
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("An unknown layer has no paths", t, func() {
+		spf, err := graph.SPFInLayer(a, d, "layer", "sr")
+		So(err, ShouldBeNil)
+		So(spf.Paths(a, d), ShouldBeEmpty)
+	})
+
+	Convey("Edges inherit the layer of their source vertex", t, func() {
+		graph.SetVertexDefaultTag(a, "layer", "sr")
+		spf, err := graph.SPFInLayer(a, d, "layer", "sr")
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->d"})
+		//The tags of the edges take precedence
+		spf, err = graph.SPFInLayer(a, d, "layer", "mpls")
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->b->d"})
+	})
+
+	Convey("Call SPFInLayer on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFInLayer(a, d, "layer", "ip")
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFAffected(t *testing.T) {
+	s := cspf.Vertex{ID: "s"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+	f := cspf.Vertex{ID: "f"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(s, a, 1), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 5), ShouldBeNil)
+		So(graph.AddEdge(s, e, 5), ShouldBeNil)
+		So(graph.AddEdge(e, f, 1), ShouldBeNil)
+	})
+
+	affected := func(edge cspf.Edge) []cspf.Vertex {
+		vertices, err := graph.SPFAffected(s, edge)
+		So(err, ShouldBeNil)
+		return vertices
+	}
+
+	Convey("A costlier edge only affects the subtree below it", t, func() {
+		So(affected(cspf.Edge{From: a, To: b, Cost: 10}), ShouldResemble, []cspf.Vertex{b, d})
+		So(affected(cspf.Edge{From: s, To: a, Cost: 1, Disabled: true}), ShouldResemble, []cspf.Vertex{a, b, c, d})
+		So(affected(cspf.Edge{From: e, To: f, Cost: 2}), ShouldResemble, []cspf.Vertex{f})
+	})
+
+	Convey("An edge off the shortest paths affects nothing when costlier", t, func() {
+		So(affected(cspf.Edge{From: c, To: d, Cost: 10}), ShouldBeEmpty)
+		So(affected(cspf.Edge{From: a, To: b, Cost: 1}), ShouldBeEmpty)
+	})
+
+	Convey("A cheaper or new edge affects the vertices it brings closer", t, func() {
+		So(affected(cspf.Edge{From: s, To: e, Cost: 1}), ShouldResemble, []cspf.Vertex{e, f})
+		So(affected(cspf.Edge{From: c, To: f, Cost: 1}), ShouldResemble, []cspf.Vertex{f})
+		//d is already at distance 3 through b
+		So(affected(cspf.Edge{From: c, To: d, Cost: 1}), ShouldBeEmpty)
+	})
+
+	Convey("Call SPFAffected on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFAffected(s, cspf.Edge{From: a, To: b})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
@@ -0,0 +1,185 @@
+package cspf
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxServedPaths caps the paths of a response, so that a client
+// cannot have the server enumerate an exponential number of them.
+const maxServedPaths = 1000
+
+// servedEvalTimeout bounds the evaluation of the expression
+// of a served CSPF request on every edge, since expressions
+// come from the network.
+const servedEvalTimeout = 100 * time.Millisecond
+
+// minAcceptDelay and maxAcceptDelay bound the back-off
+// of Serve after a temporary error of the listener.
+const (
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
+
+// Serve accepts connections on the listener and answers the
+// path computation requests of each of them on the graph, until
+// the listener fails; the error of the listener is returned.
+// Temporary errors of the listener, such as running out of file
+// descriptors, are retried with an exponential back-off instead.
+//
+// The protocol is line based. Every request is a line holding a
+// command, the source and the destination vertex IDs, separated
+// by spaces:
+//
+//	SPF <from> <to>
+//	CSPF <from> <to> <expression>
+//	PATHS <from> <to>
+//
+// SPF and CSPF answer with the shortest paths of SPF and CSPF,
+// where the expression of CSPF is the rest of the line; PATHS
+// answers with all the paths of the graph, as Paths does.
+// A successful response is a line "OK <n>" followed by n lines,
+// one per path in lexical order, rendered as the arrow-joined
+// IDs of its vertices, e.g. "A->B->D". A failed request is
+// answered with a single line "ERR <reason>", and the connection
+// stays open for the next one. A line that cannot be read, e.g.
+// because it is longer than bufio.MaxScanTokenSize, is answered
+// with an ERR line too, and then the connection is closed.
+//
+// Requests whose response would have more than maxServedPaths
+// paths fail, and so do CSPF requests whose expression takes
+// longer than servedEvalTimeout to evaluate on an edge.
+//
+// Connections are served concurrently, so the graph must not be
+// modified while Serve is running.
+func Serve(l net.Listener, g *Graph) error {
+	if g == nil {
+		return ErrNilGraph
+	}
+	var delay time.Duration
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			//Retry temporary errors, e.g. running out of file
+			//descriptors, backing off like net/http does
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if delay == 0 {
+					delay = minAcceptDelay
+				} else {
+					delay *= 2
+				}
+				if delay > maxAcceptDelay {
+					delay = maxAcceptDelay
+				}
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+		go g.serveConn(conn)
+	}
+}
+
+// serveConn answers the requests of a connection
+// until it is closed by the client or cannot be read.
+func (g *Graph) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		paths, err := g.serveRequest(line)
+		sort.Strings(paths)
+		if err != nil {
+			fmt.Fprintf(w, "ERR %v\n", err)
+		} else {
+			fmt.Fprintf(w, "OK %d\n", len(paths))
+			for _, path := range paths {
+				fmt.Fprintln(w, path)
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(w, "ERR %v\n", err)
+		w.Flush()
+	}
+}
+
+// serveRequest runs the request of a line and
+// returns the paths of its response.
+func (g *Graph) serveRequest(line string) ([]string, error) {
+	command, rest := nextField(line)
+	from, rest := nextField(rest)
+	to, rest := nextField(rest)
+	command = strings.ToUpper(command)
+	if command != "SPF" && command != "CSPF" && command != "PATHS" {
+		return nil, fmt.Errorf("unknown command %s", command)
+	}
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("usage: %s <from> <to>", command)
+	}
+	src, dst := Vertex{ID: from}, Vertex{ID: to}
+
+	result := g
+	switch command {
+	case "SPF":
+		var err error
+		result, err = g.SPF(src, dst)
+		if err != nil {
+			return nil, err
+		}
+	case "CSPF":
+		if rest == "" {
+			return nil, fmt.Errorf("usage: %s <from> <to> <expression>", command)
+		}
+		var err error
+		result, err = g.CSPF(src, dst, rest, WithEvalTimeout(servedEvalTimeout))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result.servedPaths(src, dst)
+}
+
+// errTooManyPaths stops the enumeration of the served paths.
+var errTooManyPaths = fmt.Errorf("more than %d paths", maxServedPaths)
+
+// servedPaths lists the paths of the graph like PathStrings
+// does, failing as soon as there are more than maxServedPaths.
+func (g *Graph) servedPaths(from, to Vertex) ([]string, error) {
+	paths := []string{}
+	err := g.walkPaths(from, to, func(edgeRef, Edge, int, uint64) (bool, error) {
+		if len(paths) > maxServedPaths {
+			return false, errTooManyPaths
+		}
+		return true, nil
+	}, func(path []Edge) {
+		paths = append(paths, pathString(from, path))
+	})
+	if err == nil && len(paths) > maxServedPaths {
+		err = errTooManyPaths
+	}
+	return paths, err
+}
+
+// nextField splits the first space-separated
+// field of the string from the rest of it.
+func nextField(s string) (field, rest string) {
+	s = strings.TrimLeft(s, " \t")
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimLeft(s[i:], " \t")
+}
@@ -0,0 +1,227 @@
+package cspf_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errListenerClosed = errors.New("listener closed")
+
+// temporaryError is a listener error that Serve retries
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "too many open files" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// pipeListener hands out the server side of in-memory pipes
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	// Number of temporary errors to fail with first.
+	failures int
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, temporaryError{}
+	}
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+func (l *pipeListener) dial() net.Conn {
+	server, client := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+// request sends a request line and reads back its response
+func request(conn net.Conn, r *bufio.Reader, line string) ([]string, error) {
+	if _, err := fmt.Fprintln(conn, line); err != nil {
+		return nil, err
+	}
+	status, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	status = strings.TrimSuffix(status, "\n")
+	if !strings.HasPrefix(status, "OK ") {
+		return nil, errors.New(status)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(status, "OK "))
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for i := 0; i < n; i++ {
+		path, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, strings.TrimSuffix(path, "\n"))
+	}
+	return paths, nil
+}
+
+func TestServe(t *testing.T) {
+	blue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	red := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		//Paths through C are found first, so that
+		//responses are sorted rather than in search order
+		So(graph.AddEdge(a, c, 1, red), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1, blue), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, blue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, blue), ShouldBeNil)
+	})
+
+	l := newPipeListener()
+	served := make(chan error, 1)
+	go func() {
+		served <- cspf.Serve(l, &graph)
+	}()
+
+	Convey("Answer the requests of a connection", t, func() {
+		conn := l.dial()
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		paths, err := request(conn, r, "SPF A D")
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"A->B->D", "A->C->D"})
+
+		paths, err = request(conn, r, `CSPF A D link == "blue"`)
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"A->B->D"})
+
+		paths, err = request(conn, r, "paths A D")
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"A->B->D", "A->C->D"})
+
+		paths, err = request(conn, r, "SPF D A")
+		So(err, ShouldBeNil)
+		So(paths, ShouldBeEmpty)
+	})
+
+	Convey("Answer the failed requests with an error", t, func() {
+		conn := l.dial()
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		_, err := request(conn, r, "FOO A D")
+		So(err, ShouldBeError, "ERR unknown command FOO")
+
+		_, err = request(conn, r, "SPF A")
+		So(err, ShouldBeError, "ERR usage: SPF <from> <to>")
+
+		_, err = request(conn, r, "CSPF A D")
+		So(err, ShouldBeError, "ERR usage: CSPF <from> <to> <expression>")
+
+		_, err = request(conn, r, "CSPF A D link ==")
+		So(err, ShouldNotBeNil)
+
+		//The connection is still usable
+		paths, err := request(conn, r, "SPF A B")
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"A->B"})
+	})
+
+	Convey("Refuse to enumerate too many paths", t, func() {
+		//2^10 paths connect the ends of the chain
+		chain := generateDiamondChain(10)
+		chainListener := newPipeListener()
+		go cspf.Serve(chainListener, chain)
+		defer chainListener.Close()
+		conn := chainListener.dial()
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		for _, command := range []string{"SPF", "PATHS"} {
+			_, err := request(conn, r, command+" j000 j010")
+			So(err, ShouldBeError, "ERR more than 1000 paths")
+		}
+		paths, err := request(conn, r, "SPF j000 j009")
+		So(err, ShouldBeNil)
+		So(len(paths), ShouldEqual, 512)
+	})
+
+	Convey("Report lines that are too long before closing", t, func() {
+		conn := l.dial()
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		//The pipe is synchronous, so the line is written
+		//while the response is read
+		go fmt.Fprintln(conn, "SPF A "+strings.Repeat("D", bufio.MaxScanTokenSize))
+		status, err := r.ReadString('\n')
+		So(err, ShouldBeNil)
+		So(status, ShouldEqual, "ERR "+bufio.ErrTooLong.Error()+"\n")
+		_, err = r.ReadString('\n')
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Retry the temporary errors of the listener", t, func() {
+		flaky := newPipeListener()
+		flaky.failures = 3
+		go cspf.Serve(flaky, &graph)
+		defer flaky.Close()
+		conn := flaky.dial()
+		defer conn.Close()
+		paths, err := request(conn, bufio.NewReader(conn), "SPF A B")
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"A->B"})
+	})
+
+	Convey("Return the error of the listener", t, func() {
+		So(l.Close(), ShouldBeNil)
+		So(<-served, ShouldEqual, errListenerClosed)
+	})
+
+	Convey("Serve a nil graph", t, func() {
+		So(cspf.Serve(newPipeListener(), nil), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
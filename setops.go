@@ -0,0 +1,49 @@
+package cspf
+
+// Union returns a new graph with the vertices of both graphs
+// and the edges that are in either of them. Edges are compared
+// like Equal does, by their vertices, costs, tags and state, so
+// an edge of both graphs is part of the union only once, as are
+// parallel edges that are equal.
+// Edges keep the ID they have in the graph they come from,
+// the first graph if both have the edge. A nil graph is empty.
+func Union(a, b *Graph) *Graph {
+	union := Graph{}
+	for _, g := range []*Graph{a, b} {
+		for _, v := range g.Vertices() {
+			union.addNode(v)
+			for _, edge := range g.VertexSet[v] {
+				if !containsEdge(union.VertexSet[v], edge) {
+					union.addEdge(edge.withTagsCopy())
+				}
+			}
+		}
+	}
+	return &union
+}
+
+// Intersection returns a new graph with the vertices that are
+// in both graphs and the edges that are in both of them.
+// Edges are compared like Equal does, by their vertices, costs,
+// tags and state, and parallel edges that are equal are part
+// of the intersection only once.
+// Edges keep the ID they have in the first graph.
+// A nil graph is empty.
+func Intersection(a, b *Graph) *Graph {
+	intersection := Graph{}
+	if a == nil || b == nil {
+		return &intersection
+	}
+	for _, v := range a.Vertices() {
+		if _, ok := b.VertexSet[v]; !ok {
+			continue
+		}
+		intersection.addNode(v)
+		for _, edge := range a.VertexSet[v] {
+			if containsEdge(b.VertexSet[v], edge) && !containsEdge(intersection.VertexSet[v], edge) {
+				intersection.addEdge(edge.withTagsCopy())
+			}
+		}
+	}
+	return &intersection
+}
@@ -0,0 +1,62 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnionAndIntersection(t *testing.T) {
+	a := cspf.Vertex{ID: "A"}
+	b := cspf.Vertex{ID: "B"}
+	c := cspf.Vertex{ID: "C"}
+	d := cspf.Vertex{ID: "D"}
+	tagRed := cspf.Tag{Key: "link", Value: "red"}
+	tagBlue := cspf.Tag{Key: "link", Value: "blue"}
+
+	first := cspf.Graph{}
+	second := cspf.Graph{}
+
+	Convey("Populate two overlapping graphs", t, func() {
+		So(first.AddEdge(a, b, 1, tagRed), ShouldBeNil)
+		So(first.AddEdge(b, c, 1), ShouldBeNil)
+		So(first.AddEdge(a, c, 3), ShouldBeNil)
+
+		So(second.AddEdge(a, b, 1, tagRed), ShouldBeNil)
+		//Same vertices, different cost or tags
+		So(second.AddEdge(b, c, 2), ShouldBeNil)
+		So(second.AddEdge(a, c, 3, tagBlue), ShouldBeNil)
+		So(second.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("The union merges the edges of both graphs", t, func() {
+		union := cspf.Union(&first, &second)
+		So(union.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c, d})
+		So(union.EdgeCount(), ShouldEqual, 6)
+		So(len(union.VertexSet[a]), ShouldEqual, 3)
+		So(union.VertexSet[c], ShouldResemble, []cspf.Edge{{From: c, To: d, Cost: 1}})
+		So(cspf.Union(&second, &first).Equal(union), ShouldBeTrue)
+	})
+
+	Convey("The intersection keeps only the common edges", t, func() {
+		intersection := cspf.Intersection(&first, &second)
+		So(intersection.Vertices(), ShouldResemble, []cspf.Vertex{a, b, c})
+		So(intersection.EdgeCount(), ShouldEqual, 1)
+		So(intersection.VertexSet[a], ShouldResemble, []cspf.Edge{{From: a, To: b, Cost: 1, Tags: map[string]interface{}{"link": "red"}}})
+		So(cspf.Intersection(&second, &first).Equal(intersection), ShouldBeTrue)
+	})
+
+	Convey("Results share no tags with the operands", t, func() {
+		union := cspf.Union(&first, &second)
+		union.VertexSet[a][0].Tags["link"] = "green"
+		So(first.VertexSet[a][0].Tags["link"], ShouldEqual, "red")
+	})
+
+	Convey("Nil graphs are empty", t, func() {
+		So(cspf.Union(&first, nil).Equal(&first), ShouldBeTrue)
+		So(cspf.Union(nil, nil).VertexSet, ShouldBeEmpty)
+		So(cspf.Intersection(&first, nil).VertexSet, ShouldBeEmpty)
+	})
+}
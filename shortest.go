@@ -0,0 +1,478 @@
+package cspf
+
+import (
+	"fmt"
+	"math"
+)
+
+// UnusedEdges lists the edges of the graph that are not part
+// of any shortest path originating from the given vertex,
+// i.e. the edges that are absent from the shortest-path
+// graph SPF computes for that vertex.
+// Edges are listed by source vertex ID and then in
+// insertion order.
+func (g *Graph) UnusedEdges(from Vertex) ([]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	_, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	unused := []Edge{}
+	for _, v := range g.Vertices() {
+		for _, edge := range g.VertexSet[v] {
+			if !containsEdge(prevSet[edge.To], edge) {
+				unused = append(unused, edge)
+			}
+		}
+	}
+	return unused, nil
+}
+
+func containsEdge(edges []Edge, e Edge) bool {
+	for _, edge := range edges {
+		if edge.equal(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// SPFTreesBoth computes, in a single call, the shortest distance
+// from the vertex from to every vertex of the graph (fwd) and the
+// shortest distance from every vertex of the graph to the vertex
+// to (bwd). Vertices that are not connected are left out of the
+// corresponding map.
+// Having both directions at hand enables loop-free alternate
+// checks, which compare the distance from a neighbor to the
+// destination with the distance of the paths through the source.
+func (g *Graph) SPFTreesBoth(from, to Vertex) (fwd map[Vertex]uint64, bwd map[Vertex]uint64, err error) {
+	if g == nil {
+		return nil, nil, ErrNilGraph
+	}
+	fwd, err = g.distances(from, newQuery(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	bwd, err = g.transpose().distances(to, newQuery(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	return fwd, bwd, nil
+}
+
+// DistancesToTargets computes the shortest distance from every
+// vertex of the graph to each of the given targets, e.g. to a
+// small set of anchors. The result maps every target to the
+// distances of the vertices that can reach it, including the
+// target itself at zero; vertices that cannot reach a target
+// are left out of its map.
+// Every target takes a single Dijkstra search on the transposed
+// graph, instead of one search per source vertex.
+func (g *Graph) DistancesToTargets(targets []Vertex) (map[Vertex]map[Vertex]uint64, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	reversed := g.transpose()
+	q := newQuery(nil)
+	result := make(map[Vertex]map[Vertex]uint64, len(targets))
+	for _, target := range targets {
+		if _, ok := result[target]; ok {
+			continue
+		}
+		distSet, err := reversed.distances(target, q)
+		if err != nil {
+			return nil, err
+		}
+		result[target] = distSet
+	}
+	return result, nil
+}
+
+// distances returns the shortest distance from the given
+// vertex to every vertex it can reach.
+func (g *Graph) distances(from Vertex, q *query) (map[Vertex]uint64, error) {
+	distSet, _, err := g.shortestPaths(from, q)
+	return distSet, err
+}
+
+// MaxCoveragePath returns, among all the minimum-cost paths
+// from one vertex to another, the one traversing the largest
+// number of edges that carry the given tag (with a value deeply
+// equal to tagValue), e.g. to prefer monitored links.
+// Ties are broken deterministically. The path is empty if the
+// destination cannot be reached.
+func (g *Graph) MaxCoveragePath(from, to Vertex, tagKey string, tagValue interface{}) ([]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := distSet[to]; !ok || from == to {
+		return []Edge{}, nil
+	}
+
+	//Covering more tagged edges lowers the score of a path
+	return bestShortestPath(from, to, prevSet, 0, func(score float64, edge Edge) float64 {
+		if edge.hasTag(tagKey, tagValue) {
+			return score - 1
+		}
+		return score
+	}), nil
+}
+
+// LeastLoadedPath returns, among all the minimum-cost paths from
+// one vertex to another, the one whose most utilized edge is the
+// least utilized, e.g. to spread traffic over equal-cost paths.
+// The utilization of an edge is the value of its numeric tag
+// utilTag, which every enabled edge of the graph must have,
+// otherwise ErrNotNumeric is returned. Disabled edges are never
+// traversed, so their utilization is not checked.
+// Ties are broken deterministically. The path is empty if the
+// destination cannot be reached.
+func (g *Graph) LeastLoadedPath(from, to Vertex, utilTag string) ([]Edge, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.Disabled {
+				continue
+			}
+			if _, ok := toFloat64(edge.Tags[utilTag]); !ok {
+				return nil, fmt.Errorf("%w: tag %s of edge %s->%s", ErrNotNumeric, utilTag, edge.From.ID, edge.To.ID)
+			}
+		}
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := distSet[to]; !ok || from == to {
+		return []Edge{}, nil
+	}
+
+	return bestShortestPath(from, to, prevSet, math.Inf(-1), func(peak float64, edge Edge) float64 {
+		//All the values were validated above
+		util, _ := toFloat64(edge.Tags[utilTag])
+		return math.Max(peak, util)
+	}), nil
+}
+
+// bestShortestPath picks, among the minimum-cost paths from one
+// vertex to another described by prevSet, the one with the lowest
+// score. The score of the empty path is initial, and extend
+// returns the score of a path extended with an edge. It must be
+// monotonic in the score, so that the best path to a vertex
+// always extends the best path to one of its predecessors.
+// The shortest-path graph is visited in topological order, keeping
+// for every vertex the edge that reaches it with the lowest score,
+// and ties are broken by the order of sortedEdges.
+// The destination must be reachable.
+func bestShortestPath(from, to Vertex, prevSet map[Vertex][]Edge, initial float64, extend func(score float64, edge Edge) float64) []Edge {
+	successors := make(map[Vertex][]Edge)
+	pending := make(map[Vertex]int)
+	for v, edges := range prevSet {
+		pending[v] = len(edges)
+		for _, edge := range sortedEdges(edges) {
+			successors[edge.From] = append(successors[edge.From], edge)
+		}
+	}
+	score := map[Vertex]float64{from: initial}
+	bestEdge := make(map[Vertex]Edge)
+	ready := []Vertex{from}
+	for len(ready) > 0 {
+		v := ready[0]
+		ready = ready[1:]
+		for _, edge := range successors[v] {
+			extended := extend(score[v], edge)
+			if _, ok := bestEdge[edge.To]; !ok || extended < score[edge.To] {
+				score[edge.To] = extended
+				bestEdge[edge.To] = edge
+			}
+			pending[edge.To]--
+			if pending[edge.To] == 0 {
+				ready = append(ready, edge.To)
+			}
+		}
+	}
+
+	path := []Edge{}
+	for v := to; v != from; v = bestEdge[v].From {
+		path = append(path, bestEdge[v])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// VertexDistance pairs a vertex with its distance from a source.
+type VertexDistance struct {
+	// Vertex is the reached vertex.
+	Vertex Vertex
+	// Distance is the cost of the shortest path to the vertex.
+	Distance uint64
+}
+
+// KNearest returns the k vertices closest to the given one,
+// excluding the vertex itself and the vertices it cannot reach.
+// Vertices are ordered by distance, ties are broken by vertex ID.
+// Fewer than k vertices are returned if not enough are reachable.
+// The Dijkstra search stops as soon as k vertices are settled,
+// so the rest of the graph is not explored.
+func (g *Graph) KNearest(from Vertex, k int) ([]VertexDistance, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	nearest := []VertexDistance{}
+	if _, ok := g.VertexSet[from]; !ok || k <= 0 {
+		return nearest, nil
+	}
+
+	distSet := map[Vertex]uint64{from: 0}
+	settled := make(map[Vertex]bool)
+	queue := vertexQueue{}
+	queue.push(from, 0)
+	for queue.Len() > 0 && len(nearest) < k {
+		item := queue.pop()
+		if settled[item.vertex] {
+			continue
+		}
+		settled[item.vertex] = true
+		if item.vertex != from {
+			nearest = append(nearest, VertexDistance{Vertex: item.vertex, Distance: item.dist})
+		}
+		for _, edge := range g.VertexSet[item.vertex] {
+			if edge.Disabled || settled[edge.To] {
+				continue
+			}
+			dist := addCost(item.dist, edge.Cost)
+			if current, ok := distSet[edge.To]; dist != infinity && (!ok || dist < current) {
+				distSet[edge.To] = dist
+				queue.push(edge.To, dist)
+			}
+		}
+	}
+	return nearest, nil
+}
+
+// SPFParents computes the shortest paths from the given vertex
+// to every vertex it can reach, as parent pointers: the parent
+// of a vertex is the vertex preceding it on a shortest path.
+// Among equal-cost paths, the parent with the smallest ID is
+// chosen. It also returns the distance of every reachable vertex,
+// including the source itself, which has no parent.
+// Following the parents back from a vertex to the source gives
+// one of the paths SPF lists for the two.
+func (g *Graph) SPFParents(from Vertex) (map[Vertex]Vertex, map[Vertex]uint64, error) {
+	if g == nil {
+		return nil, nil, ErrNilGraph
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	parents := make(map[Vertex]Vertex)
+	for v := range distSet {
+		for i, edge := range prevSet[v] {
+			if i == 0 || edge.From.ID < parents[v].ID {
+				parents[v] = edge.From
+			}
+		}
+	}
+	return parents, distSet, nil
+}
+
+// SPFInto computes the same result graph as SPF and merges its
+// edges into dst, skipping the edges dst already contains.
+// Calling it for several sources accumulates the union of
+// their shortest-path graphs in dst. The merged edges are
+// added to dst like AddEdge does, with their own copy of the
+// tags, so they are recorded if dst records its mutations.
+func (g *Graph) SPFInto(dst *Graph, from, to Vertex) error {
+	if g == nil || dst == nil {
+		return ErrNilGraph
+	}
+	spf, err := g.spf(from, to, newQuery(nil))
+	if err != nil {
+		return err
+	}
+	for _, v := range spf.Vertices() {
+		for _, edge := range spf.VertexSet[v] {
+			if !containsEdge(dst.VertexSet[edge.From], edge) {
+				dst.insertEdge(edge.withTagsCopy())
+			}
+		}
+	}
+	return nil
+}
+
+// SPFFromEdge builds a result graph with the shortest paths
+// from one vertex to another that begin with the given edge,
+// e.g. when policy fixes the first hop. The paths continue
+// along the shortest paths from the end of the first edge to
+// the destination, and the result graph contains only the
+// edges of such paths. The continuation never goes back through
+// the source of the first edge, so that the paths are simple.
+// It is empty if the destination cannot be reached that way.
+// The first edge must be an enabled edge of the graph, otherwise
+// ErrEdgeNotFound is returned.
+func (g *Graph) SPFFromEdge(first Edge, to Vertex) (*Graph, error) {
+	if g == nil {
+		return nil, ErrNilGraph
+	}
+	if !containsEdge(g.VertexSet[first.From], first) {
+		return nil, fmt.Errorf("%w: %s->%s", ErrEdgeNotFound, first.From.ID, first.To.ID)
+	}
+	if first.Disabled {
+		return nil, fmt.Errorf("%w: %s->%s is disabled", ErrEdgeNotFound, first.From.ID, first.To.ID)
+	}
+	result := Graph{}
+	if first.From == first.To {
+		//A self-loop cannot start a simple path
+		return &result, nil
+	}
+	q := newQuery(nil)
+	q.filters = append(q.filters, func(e Edge) bool {
+		return e.To != first.From
+	})
+	distSet, prevSet, err := g.shortestPaths(first.To, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := distSet[to]; !ok {
+		return &result, nil
+	}
+	result.addEdge(first)
+	result.addPathsTo(prevSet, to)
+	return &result, nil
+}
+
+// addPathsTo adds to the graph the edges of the shortest paths
+// to the given vertex, walking its predecessors back to the
+// source, so that only the edges leading to it are added.
+func (g *Graph) addPathsTo(prevSet map[Vertex][]Edge, to Vertex) {
+	visited := map[Vertex]bool{to: true}
+	pending := []Vertex{to}
+	for len(pending) > 0 {
+		v := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, edge := range prevSet[v] {
+			g.addEdge(edge)
+			if !visited[edge.From] {
+				visited[edge.From] = true
+				pending = append(pending, edge.From)
+			}
+		}
+	}
+}
+
+// AnycastTree computes the shortest paths from one vertex to
+// the nearest member of a group of equivalent destinations, and
+// returns a result graph with only the edges of the shortest
+// paths to that member, together with the selected member.
+// Members at the same distance are told apart by their ID.
+// If no member can be reached, the result graph is empty and
+// the selected member is the zero Vertex.
+func (g *Graph) AnycastTree(from Vertex, group []Vertex) (*Graph, Vertex, error) {
+	if g == nil {
+		return nil, Vertex{}, ErrNilGraph
+	}
+	distSet, prevSet, err := g.shortestPaths(from, newQuery(nil))
+	if err != nil {
+		return nil, Vertex{}, err
+	}
+	var nearest Vertex
+	found := false
+	for _, member := range group {
+		dist, ok := distSet[member]
+		if !ok {
+			continue
+		}
+		if !found || dist < distSet[nearest] || (dist == distSet[nearest] && member.ID < nearest.ID) {
+			nearest = member
+			found = true
+		}
+	}
+	if !found {
+		return &Graph{}, Vertex{}, nil
+	}
+	result := Graph{}
+	result.addPathsTo(prevSet, nearest)
+	return &result, nearest, nil
+}
+
+// SPFBetweenGroups finds the shortest path from any vertex of
+// groupA to any vertex of groupB, e.g. between two regions.
+// It returns the path, as a result graph and as a list of edges
+// whose first source and last destination are the endpoints in
+// the two groups, along with its cost.
+// The search is a single Dijkstra algorithm seeded with all the
+// vertices of groupA, which stops as soon as a vertex of groupB
+// is settled. Paths of equal cost are told apart by the IDs of
+// their vertices. If the groups share a vertex, the path is
+// empty, with cost 0, and the result graph holds that vertex.
+// If no vertex of groupB can be reached, the result graph and
+// the path are empty and the cost is Unreachable.
+func (g *Graph) SPFBetweenGroups(groupA, groupB []Vertex) (*Graph, []Edge, uint64, error) {
+	if g == nil {
+		return nil, nil, 0, ErrNilGraph
+	}
+	targets := make(map[Vertex]bool, len(groupB))
+	for _, v := range groupB {
+		targets[v] = true
+	}
+	distSet := make(map[Vertex]uint64)
+	prevEdge := make(map[Vertex]Edge)
+	settled := make(map[Vertex]bool)
+	queue := vertexQueue{}
+	for _, v := range groupA {
+		if _, ok := g.VertexSet[v]; ok {
+			distSet[v] = 0
+			queue.push(v, 0)
+		}
+	}
+
+	for queue.Len() > 0 {
+		item := queue.pop()
+		if settled[item.vertex] {
+			continue
+		}
+		settled[item.vertex] = true
+		if targets[item.vertex] {
+			//The seeds are the only vertices with no previous edge,
+			//since no path can improve on their zero distance
+			path := []Edge{}
+			for edge, ok := prevEdge[item.vertex]; ok; edge, ok = prevEdge[edge.From] {
+				path = append(path, edge)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			result := Graph{}
+			result.addNode(item.vertex)
+			for _, edge := range path {
+				result.addEdge(edge)
+			}
+			return &result, path, item.dist, nil
+		}
+		for _, edge := range g.VertexSet[item.vertex] {
+			if edge.Disabled || settled[edge.To] {
+				continue
+			}
+			dist := addCost(item.dist, edge.Cost)
+			if current, ok := distSet[edge.To]; dist != infinity && (!ok || dist < current) {
+				distSet[edge.To] = dist
+				prevEdge[edge.To] = edge
+				queue.push(edge.To, dist)
+			}
+		}
+	}
+	return &Graph{}, []Edge{}, Unreachable, nil
+}
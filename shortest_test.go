@@ -0,0 +1,595 @@
+package cspf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnusedEdges(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with an unequal-cost diamond", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 3), ShouldBeNil)
+	})
+
+	Convey("The costlier edge is reported as unused", t, func() {
+		unused, err := graph.UnusedEdges(a)
+		So(err, ShouldBeNil)
+		So(len(unused), ShouldEqual, 1)
+		So(unused[0].From, ShouldResemble, c)
+		So(unused[0].To, ShouldResemble, d)
+	})
+
+	Convey("Edges not reachable from the source are unused", t, func() {
+		unused, err := graph.UnusedEdges(d)
+		So(err, ShouldBeNil)
+		So(len(unused), ShouldEqual, 4)
+	})
+
+	Convey("Call UnusedEdges on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		unused, err := nilGraph.UnusedEdges(a)
+		So(unused, ShouldBeNil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFTreesBoth(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 4), ShouldBeNil)
+		So(graph.AddEdge(b, c, 2), ShouldBeNil)
+		So(graph.AddEdge(b, d, 5), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, e, 3), ShouldBeNil)
+	})
+
+	Convey("Compute the distances in both directions", t, func() {
+		fwd, bwd, err := graph.SPFTreesBoth(a, d)
+		So(err, ShouldBeNil)
+		So(fwd, ShouldResemble, map[cspf.Vertex]uint64{a: 0, b: 1, c: 3, d: 4, e: 7})
+		//e cannot reach d
+		So(bwd, ShouldResemble, map[cspf.Vertex]uint64{a: 4, b: 3, c: 1, d: 0})
+	})
+
+	Convey("Check a loop-free alternate with both distance maps", t, func() {
+		fwd, bwd, err := graph.SPFTreesBoth(a, d)
+		So(err, ShouldBeNil)
+		//Neighbor c of a is a loop-free alternate
+		//towards d: its shortest path does not go back to a
+		So(bwd[c], ShouldBeLessThan, fwd[c]+bwd[a])
+	})
+
+	Convey("Call SPFTreesBoth on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		fwd, bwd, err := nilGraph.SPFTreesBoth(a, d)
+		So(fwd, ShouldBeNil)
+		So(bwd, ShouldBeNil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestDistancesToTargets(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 4), ShouldBeNil)
+		So(graph.AddEdge(b, c, 2), ShouldBeNil)
+		So(graph.AddEdge(b, d, 5), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, e, 3), ShouldBeNil)
+	})
+
+	Convey("Compute the distances to the targets", t, func() {
+		matrix, err := graph.DistancesToTargets([]cspf.Vertex{d, b})
+		So(err, ShouldBeNil)
+		So(len(matrix), ShouldEqual, 2)
+		//e cannot reach d
+		So(matrix[d], ShouldResemble, map[cspf.Vertex]uint64{a: 4, b: 3, c: 1, d: 0})
+		So(matrix[b], ShouldResemble, map[cspf.Vertex]uint64{a: 1, b: 0})
+	})
+
+	Convey("Cross-check with the distances from every source", t, func() {
+		targets := graph.Vertices()
+		matrix, err := graph.DistancesToTargets(targets)
+		So(err, ShouldBeNil)
+		for _, source := range graph.Vertices() {
+			_, distances, err := graph.SPFParents(source)
+			So(err, ShouldBeNil)
+			for _, target := range targets {
+				dist, ok := distances[target]
+				toTarget, found := matrix[target][source]
+				So(found, ShouldEqual, ok)
+				So(toTarget, ShouldEqual, dist)
+			}
+		}
+	})
+
+	Convey("Call DistancesToTargets on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		matrix, err := nilGraph.DistancesToTargets([]cspf.Vertex{a})
+		So(matrix, ShouldBeNil)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestMaxCoveragePath(t *testing.T) {
+	monitored := cspf.Tag{
+		Key:   "monitored",
+		Value: true,
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with equal-cost paths", t, func() {
+		So(graph.AddEdge(a, b, 1, monitored), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, monitored), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, monitored), ShouldBeNil)
+		//A cheaper unmonitored path would win on cost
+		So(graph.AddEdge(a, e, 1), ShouldBeNil)
+		So(graph.AddEdge(e, d, 2, monitored), ShouldBeNil)
+	})
+
+	Convey("Select the shortest path with the most monitored links", t, func() {
+		path, err := graph.MaxCoveragePath(a, d, "monitored", true)
+		So(err, ShouldBeNil)
+		So(cspf.Path(path).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("Coverage never trades off cost", t, func() {
+		So(graph.AddEdge(a, d, 1), ShouldBeNil)
+		path, err := graph.MaxCoveragePath(a, d, "monitored", true)
+		So(err, ShouldBeNil)
+		So(cspf.Path(path).String(), ShouldEqual, "a->d")
+	})
+
+	Convey("An unreachable destination has an empty path", t, func() {
+		path, err := graph.MaxCoveragePath(d, a, "monitored", true)
+		So(err, ShouldBeNil)
+		So(path, ShouldBeEmpty)
+	})
+
+	Convey("Call MaxCoveragePath on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.MaxCoveragePath(a, d, "monitored", true)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestLeastLoadedPath(t *testing.T) {
+	utilization := func(value interface{}) cspf.Tag {
+		return cspf.Tag{Key: "utilization", Value: value}
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate two equal-cost paths with different peaks", t, func() {
+		//The path through b is less utilized on average,
+		//but its peak is higher than the one through c
+		So(graph.AddEdge(a, b, 1, utilization(0.9)), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, utilization(0.1)), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, utilization(0.5)), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1, utilization(0.6)), ShouldBeNil)
+		//An idle path that costs more
+		So(graph.AddEdge(a, e, 2, utilization(0)), ShouldBeNil)
+		So(graph.AddEdge(e, d, 2, utilization(0)), ShouldBeNil)
+	})
+
+	Convey("Pick the equal-cost path with the lowest peak", t, func() {
+		path, err := graph.LeastLoadedPath(a, d, "utilization")
+		So(err, ShouldBeNil)
+		So(cspf.Path(path).String(), ShouldEqual, "a->c->d")
+	})
+
+	Convey("The path is empty if the destination cannot be reached", t, func() {
+		path, err := graph.LeastLoadedPath(d, a, "utilization")
+		So(err, ShouldBeNil)
+		So(path, ShouldBeEmpty)
+	})
+
+	Convey("Missing and non-numeric utilizations are reported as errors", t, func() {
+		missing := cspf.Graph{}
+		So(missing.AddEdge(a, b, 1), ShouldBeNil)
+		_, err := missing.LeastLoadedPath(a, b, "utilization")
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+
+		invalid := cspf.Graph{}
+		So(invalid.AddEdge(a, b, 1, utilization("high")), ShouldBeNil)
+		_, err = invalid.LeastLoadedPath(a, b, "utilization")
+		So(errors.Is(err, cspf.ErrNotNumeric), ShouldBeTrue)
+	})
+
+	Convey("Disabled edges are neither traversed nor checked", t, func() {
+		disabled := cspf.Graph{}
+		So(disabled.AddEdge(a, b, 1, utilization(0.2)), ShouldBeNil)
+		So(disabled.AddEdge(a, c, 1), ShouldBeNil)
+		So(disabled.SetEdgeEnabled(a, c, false), ShouldEqual, 1)
+		path, err := disabled.LeastLoadedPath(a, b, "utilization")
+		So(err, ShouldBeNil)
+		So(cspf.Path(path).String(), ShouldEqual, "a->b")
+	})
+
+	Convey("Call LeastLoadedPath on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.LeastLoadedPath(a, d, "utilization")
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestKNearest(t *testing.T) {
+	hub := cspf.Vertex{ID: "hub"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a weighted star", t, func() {
+		So(graph.AddEdge(hub, d, 4), ShouldBeNil)
+		So(graph.AddEdge(hub, a, 3), ShouldBeNil)
+		So(graph.AddEdge(hub, c, 1), ShouldBeNil)
+		So(graph.AddEdge(hub, b, 3), ShouldBeNil)
+		//Not reachable from the hub
+		So(graph.AddEdge(e, hub, 1), ShouldBeNil)
+	})
+
+	Convey("Vertices are ordered by distance and then by ID", t, func() {
+		nearest, err := graph.KNearest(hub, 3)
+		So(err, ShouldBeNil)
+		So(nearest, ShouldResemble, []cspf.VertexDistance{
+			{Vertex: c, Distance: 1},
+			{Vertex: a, Distance: 3},
+			{Vertex: b, Distance: 3},
+		})
+	})
+
+	Convey("Fewer than k reachable vertices returns all of them", t, func() {
+		nearest, err := graph.KNearest(hub, 10)
+		So(err, ShouldBeNil)
+		So(len(nearest), ShouldEqual, 4)
+		So(nearest[3], ShouldResemble, cspf.VertexDistance{Vertex: d, Distance: 4})
+	})
+
+	Convey("Non-positive k returns no vertices", t, func() {
+		nearest, err := graph.KNearest(hub, 0)
+		So(err, ShouldBeNil)
+		So(nearest, ShouldBeEmpty)
+	})
+
+	Convey("Distances account for multi-hop paths", t, func() {
+		multi := cspf.Graph{}
+		So(multi.AddEdge(a, b, 5), ShouldBeNil)
+		So(multi.AddEdge(a, c, 1), ShouldBeNil)
+		So(multi.AddEdge(c, b, 1), ShouldBeNil)
+		So(multi.AddEdge(b, d, 1), ShouldBeNil)
+		So(multi.AddEdge(c, e, 10), ShouldBeNil)
+		nearest, err := multi.KNearest(a, 3)
+		So(err, ShouldBeNil)
+		So(nearest, ShouldResemble, []cspf.VertexDistance{
+			{Vertex: c, Distance: 1},
+			{Vertex: b, Distance: 2},
+			{Vertex: d, Distance: 3},
+		})
+	})
+
+	Convey("Call KNearest on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.KNearest(hub, 1)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFInto(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, d, 5), ShouldBeNil)
+	})
+
+	Convey("Accumulate the shortest paths of two sources", t, func() {
+		union := cspf.Graph{}
+		So(graph.SPFInto(&union, a, d), ShouldBeNil)
+		So(graph.SPFInto(&union, b, d), ShouldBeNil)
+		So(union.VertexSet[a], ShouldResemble, []cspf.Edge{{From: a, To: c, Cost: 1}})
+		So(union.VertexSet[b], ShouldResemble, []cspf.Edge{{From: b, To: c, Cost: 1}})
+		//The shared c -> d edge is merged only once
+		So(union.VertexSet[c], ShouldResemble, []cspf.Edge{{From: c, To: d, Cost: 1}})
+		So(len(union.Paths(a, d)), ShouldEqual, 1)
+	})
+
+	Convey("Merged edges own their tags and are recorded", t, func() {
+		tagged := cspf.Graph{}
+		So(tagged.AddEdge(a, b, 1, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+		union := cspf.Graph{RecordMutations: true}
+		So(tagged.SPFInto(&union, a, b), ShouldBeNil)
+		So(union.Mutations(), ShouldResemble, []cspf.Mutation{
+			{Kind: cspf.MutationAddEdge, Edge: union.VertexSet[a][0]},
+		})
+		union.VertexSet[a][0].Tags["link"] = "red"
+		So(tagged.VertexSet[a][0].Tags["link"], ShouldEqual, "blue")
+	})
+
+	Convey("Call SPFInto with nil graphs", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.SPFInto(&cspf.Graph{}, a, d), ShouldBeError, cspf.ErrNilGraph)
+		So(graph.SPFInto(nil, a, d), ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFFromEdge(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 5), ShouldBeNil)
+		So(graph.AddEdge(c, b, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(c, e, 1), ShouldBeNil)
+	})
+
+	Convey("Force a suboptimal first hop", t, func() {
+		first := cspf.Edge{From: a, To: c, Cost: 5}
+		spf, err := graph.SPFFromEdge(first, d)
+		So(err, ShouldBeNil)
+		paths := spf.Paths(a, d)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		//Edges that do not lead to the destination are left out
+		So(spf.VertexSet[c], ShouldResemble, []cspf.Edge{{From: c, To: d, Cost: 1}})
+	})
+
+	Convey("An unreachable destination gives an empty graph", t, func() {
+		spf, err := graph.SPFFromEdge(cspf.Edge{From: a, To: b, Cost: 1}, e)
+		So(err, ShouldBeNil)
+		So(spf.VertexSet, ShouldBeEmpty)
+	})
+
+	Convey("The first edge must be part of the graph", t, func() {
+		_, err := graph.SPFFromEdge(cspf.Edge{From: a, To: c, Cost: 1}, d)
+		So(errors.Is(err, cspf.ErrEdgeNotFound), ShouldBeTrue)
+	})
+
+	Convey("The first edge must be enabled", t, func() {
+		disabled := cspf.Graph{}
+		So(disabled.AddEdge(a, b, 1), ShouldBeNil)
+		So(disabled.SetEdgeEnabled(a, b, false), ShouldEqual, 1)
+		_, err := disabled.SPFFromEdge(disabled.VertexSet[a][0], b)
+		So(errors.Is(err, cspf.ErrEdgeNotFound), ShouldBeTrue)
+	})
+
+	Convey("The paths do not loop back through the first vertex", t, func() {
+		loop := cspf.Graph{}
+		So(loop.AddEdge(a, c, 1), ShouldBeNil)
+		So(loop.AddEdge(c, a, 1), ShouldBeNil)
+		So(loop.AddEdge(a, d, 1), ShouldBeNil)
+		So(loop.AddEdge(c, d, 5), ShouldBeNil)
+		spf, err := loop.SPFFromEdge(cspf.Edge{From: a, To: c, Cost: 1}, d)
+		So(err, ShouldBeNil)
+		So(spf.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		So(spf.VertexSet[c], ShouldResemble, []cspf.Edge{{From: c, To: d, Cost: 5}})
+
+		spf, err = loop.SPFFromEdge(cspf.Edge{From: a, To: c, Cost: 1}, a)
+		So(err, ShouldBeNil)
+		So(spf.VertexSet, ShouldBeEmpty)
+	})
+
+	Convey("Call SPFFromEdge on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, err := nilGraph.SPFFromEdge(cspf.Edge{From: a, To: c, Cost: 5}, d)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestAnycastTree(t *testing.T) {
+	src := cspf.Vertex{ID: "src"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	replica1 := cspf.Vertex{ID: "replica1"}
+	replica2 := cspf.Vertex{ID: "replica2"}
+	isolated := cspf.Vertex{ID: "isolated"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(src, a, 1), ShouldBeNil)
+		So(graph.AddEdge(a, replica1, 5), ShouldBeNil)
+		So(graph.AddEdge(src, b, 2), ShouldBeNil)
+		So(graph.AddEdge(b, replica2, 2), ShouldBeNil)
+		graph.AddNode(isolated)
+	})
+
+	Convey("Select the nearest member of the group", t, func() {
+		tree, member, err := graph.AnycastTree(src, []cspf.Vertex{replica1, replica2})
+		So(err, ShouldBeNil)
+		So(member, ShouldResemble, replica2)
+		paths := tree.Paths(src, replica2)
+		So(len(paths), ShouldEqual, 1)
+		So(cspf.Path(paths[0]).String(), ShouldEqual, "src->b->replica2")
+		//The branch towards the farther member is left out
+		So(tree.VertexSet[a], ShouldBeEmpty)
+		So(tree.EdgeCount(), ShouldEqual, 2)
+	})
+
+	Convey("Unreachable members are ignored", t, func() {
+		_, member, err := graph.AnycastTree(src, []cspf.Vertex{isolated, replica1})
+		So(err, ShouldBeNil)
+		So(member, ShouldResemble, replica1)
+	})
+
+	Convey("No reachable member gives an empty tree", t, func() {
+		tree, member, err := graph.AnycastTree(src, []cspf.Vertex{isolated})
+		So(err, ShouldBeNil)
+		So(member, ShouldResemble, cspf.Vertex{})
+		So(tree.VertexSet, ShouldBeEmpty)
+	})
+
+	Convey("Call AnycastTree on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, err := nilGraph.AnycastTree(src, []cspf.Vertex{replica1})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFParents(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	e := cspf.Vertex{ID: "e"}
+	f := cspf.Vertex{ID: "f"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		//Equal-cost paths to d through c and b
+		So(graph.AddEdge(a, c, 1), ShouldBeNil)
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(d, e, 2), ShouldBeNil)
+		So(graph.AddEdge(a, e, 5), ShouldBeNil)
+		So(graph.AddEdge(f, a, 1), ShouldBeNil)
+	})
+
+	Convey("Every reachable vertex has one parent", t, func() {
+		parents, dist, err := graph.SPFParents(a)
+		So(err, ShouldBeNil)
+		So(parents, ShouldResemble, map[cspf.Vertex]cspf.Vertex{b: a, c: a, d: b, e: d})
+		So(dist, ShouldResemble, map[cspf.Vertex]uint64{a: 0, b: 1, c: 1, d: 2, e: 4})
+	})
+
+	Convey("Parents rebuild one of the shortest paths", t, func() {
+		parents, dist, err := graph.SPFParents(a)
+		So(err, ShouldBeNil)
+		spfGraph, err := graph.SPF(a, e)
+		So(err, ShouldBeNil)
+		path := []cspf.Vertex{e}
+		for v := e; v != a; v = parents[v] {
+			path = append([]cspf.Vertex{parents[v]}, path...)
+		}
+		found := false
+		for _, spfPath := range spfGraph.PathList(a, e) {
+			if reflect.DeepEqual(spfPath.Vertices(), path) {
+				found = true
+				So(spfPath.Cost(), ShouldEqual, dist[e])
+			}
+		}
+		So(found, ShouldBeTrue)
+	})
+
+	Convey("Call SPFParents on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, err := nilGraph.SPFParents(a)
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
+
+func TestSPFBetweenGroups(t *testing.T) {
+	a1 := cspf.Vertex{ID: "a1"}
+	a2 := cspf.Vertex{ID: "a2"}
+	a3 := cspf.Vertex{ID: "a3"}
+	b1 := cspf.Vertex{ID: "b1"}
+	b2 := cspf.Vertex{ID: "b2"}
+	x := cspf.Vertex{ID: "x"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate two regions with several links between them", t, func() {
+		So(graph.AddMixedEdge(a1, a2, 1, true), ShouldBeNil)
+		So(graph.AddMixedEdge(a2, a3, 1, true), ShouldBeNil)
+		So(graph.AddMixedEdge(b1, b2, 1, true), ShouldBeNil)
+		So(graph.AddEdge(a1, b1, 10), ShouldBeNil)
+		So(graph.AddEdge(a2, x, 3), ShouldBeNil)
+		So(graph.AddEdge(x, b2, 3), ShouldBeNil)
+		So(graph.AddEdge(a3, b2, 7), ShouldBeNil)
+	})
+
+	Convey("Find the closest pair of vertices across the groups", t, func() {
+		result, path, cost, err := graph.SPFBetweenGroups([]cspf.Vertex{a1, a2, a3}, []cspf.Vertex{b1, b2})
+		So(err, ShouldBeNil)
+		So(cost, ShouldEqual, 6)
+		So(cspf.Path(path).String(), ShouldEqual, "a2->x->b2")
+		So(result.PathStrings(a2, b2), ShouldResemble, []string{"a2->x->b2"})
+		So(result.EdgeCount(), ShouldEqual, 2)
+	})
+
+	Convey("Groups sharing a vertex are at distance 0", t, func() {
+		result, path, cost, err := graph.SPFBetweenGroups([]cspf.Vertex{a1, x}, []cspf.Vertex{x, b1})
+		So(err, ShouldBeNil)
+		So(cost, ShouldEqual, 0)
+		So(path, ShouldBeEmpty)
+		So(result.Vertices(), ShouldResemble, []cspf.Vertex{x})
+	})
+
+	Convey("Unreachable groups have no path", t, func() {
+		result, path, cost, err := graph.SPFBetweenGroups([]cspf.Vertex{b1, b2}, []cspf.Vertex{a1, a2})
+		So(err, ShouldBeNil)
+		So(cost, ShouldEqual, cspf.Unreachable)
+		So(path, ShouldBeEmpty)
+		So(result.VertexCount(), ShouldEqual, 0)
+	})
+
+	Convey("Call SPFBetweenGroups on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		_, _, _, err := nilGraph.SPFBetweenGroups([]cspf.Vertex{a1}, []cspf.Vertex{b1})
+		So(err, ShouldBeError, cspf.ErrNilGraph)
+	})
+}
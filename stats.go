@@ -0,0 +1,220 @@
+package cspf
+
+import (
+	"math"
+	"sort"
+)
+
+// Degree counts the edges entering and leaving a vertex.
+type Degree struct {
+	// In is the number of edges ending at the vertex.
+	In int
+	// Out is the number of edges starting from the vertex.
+	Out int
+}
+
+// GraphStats summarizes the structure of a graph.
+type GraphStats struct {
+	// Number of vertices.
+	Vertices int
+	// Number of edges, including self-loops and parallel edges.
+	Edges int
+	// Density of the graph, as computed by Density.
+	Density float64
+	// Average number of edges entering or leaving a vertex.
+	AverageDegree float64
+	// Whether every vertex can be reached from every other
+	// one, ignoring the direction of the edges.
+	Connected bool
+}
+
+// VertexCount returns the number of vertices of the graph.
+func (g *Graph) VertexCount() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.VertexSet)
+}
+
+// EdgeCount returns the number of edges of the graph.
+func (g *Graph) EdgeCount() int {
+	if g == nil {
+		return 0
+	}
+	count := 0
+	for _, edges := range g.VertexSet {
+		count += len(edges)
+	}
+	return count
+}
+
+// Degrees returns the in-degree and the out-degree
+// of every vertex of the graph.
+func (g *Graph) Degrees() map[Vertex]Degree {
+	if g == nil {
+		return nil
+	}
+	degrees := make(map[Vertex]Degree, len(g.VertexSet))
+	for v, edges := range g.VertexSet {
+		degree := degrees[v]
+		degree.Out = len(edges)
+		degrees[v] = degree
+		for _, edge := range edges {
+			degree := degrees[edge.To]
+			degree.In++
+			degrees[edge.To] = degree
+		}
+	}
+	return degrees
+}
+
+// CostHistogram counts the edges of the graph by cost, in the
+// buckets delimited by the given upper bounds: the bucket of bound
+// b counts the edges whose cost is at most b and larger than the
+// previous bound. Every bound is a key of the histogram, even if
+// no edge falls in its bucket, and the edges costing more than
+// the largest bound are counted under Unreachable.
+// Bounds can be given in any order, and all the edges count,
+// including the disabled ones.
+func (g *Graph) CostHistogram(buckets []uint64) map[uint64]int {
+	if g == nil {
+		return nil
+	}
+	bounds := append([]uint64(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	histogram := make(map[uint64]int, len(bounds)+1)
+	for _, bound := range bounds {
+		histogram[bound] = 0
+	}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			i := sort.Search(len(bounds), func(i int) bool {
+				return bounds[i] >= edge.Cost
+			})
+			if i == len(bounds) {
+				histogram[Unreachable]++
+			} else {
+				histogram[bounds[i]]++
+			}
+		}
+	}
+	return histogram
+}
+
+// CostPercentile returns the p-th percentile of the costs of the
+// edges of the graph, with p from 0 to 100, using the nearest-rank
+// method: it is the smallest cost such that at least p percent of
+// the edges cost no more than it, e.g. the median for 50.
+// Values of p out of range are clamped, and all the edges count,
+// including the disabled ones. A graph with no edges returns 0.
+func (g *Graph) CostPercentile(p float64) uint64 {
+	if g == nil {
+		return 0
+	}
+	costs := []uint64{}
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			costs = append(costs, edge.Cost)
+		}
+	}
+	if len(costs) == 0 {
+		return 0
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i] < costs[j]
+	})
+	rank := math.Ceil(p / 100 * float64(len(costs)))
+	if rank < 1 || math.IsNaN(rank) {
+		rank = 1
+	}
+	if rank > float64(len(costs)) {
+		rank = float64(len(costs))
+	}
+	return costs[int(rank)-1]
+}
+
+// Stats computes aggregate metrics that characterize the graph.
+func (g *Graph) Stats() GraphStats {
+	stats := GraphStats{
+		Vertices: g.VertexCount(),
+		Edges:    g.EdgeCount(),
+	}
+	if stats.Vertices == 0 {
+		return stats
+	}
+
+	degrees := g.Degrees()
+	totalDegree := 0
+	for _, degree := range degrees {
+		totalDegree += degree.In + degree.Out
+	}
+	stats.AverageDegree = float64(totalDegree) / float64(stats.Vertices)
+
+	stats.Density = g.Density()
+	stats.Connected = g.weaklyConnected()
+	return stats
+}
+
+// Density returns the ratio of connected ordered pairs of
+// distinct vertices to all the possible ones, i.e. n*(n-1)
+// for n vertices. Self-loops are ignored and parallel edges
+// count once, so the density ranges from 0 to 1.
+func (g *Graph) Density() float64 {
+	n := g.VertexCount()
+	if n < 2 {
+		return 0
+	}
+	type pair struct{ from, to Vertex }
+	pairs := make(map[pair]bool)
+	for v, edges := range g.VertexSet {
+		for _, edge := range edges {
+			if edge.To != v {
+				pairs[pair{from: v, to: edge.To}] = true
+			}
+		}
+	}
+	return float64(len(pairs)) / (float64(n) * float64(n-1))
+}
+
+// IsStronglyConnected reports whether every vertex of the
+// graph can reach every other one following the direction
+// of the enabled edges. A graph with no vertices is not connected.
+func (g *Graph) IsStronglyConnected() bool {
+	if g.VertexCount() == 0 {
+		return false
+	}
+	_, count := g.sccLabels()
+	return count == 1
+}
+
+// weaklyConnected reports whether all the vertices belong
+// to the same component when edges are taken as undirected.
+func (g *Graph) weaklyConnected() bool {
+	neighbors := make(map[Vertex][]Vertex, len(g.VertexSet))
+	for v, edges := range g.VertexSet {
+		for _, edge := range edges {
+			neighbors[v] = append(neighbors[v], edge.To)
+			neighbors[edge.To] = append(neighbors[edge.To], v)
+		}
+	}
+	var start Vertex
+	for v := range g.VertexSet {
+		start = v
+		break
+	}
+	reached := map[Vertex]bool{start: true}
+	frontier := []Vertex{start}
+	for len(frontier) > 0 {
+		v := frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		for _, n := range neighbors[v] {
+			if !reached[n] {
+				reached[n] = true
+				frontier = append(frontier, n)
+			}
+		}
+	}
+	return len(reached) == len(g.VertexSet)
+}
@@ -0,0 +1,129 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStats(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	Convey("The fully connected graph has density 1", t, func() {
+		//The generated graph also has a self-loop on every vertex
+		graph, _ := generateFullyConnectedGraph(10, false)
+		stats := graph.Stats()
+		So(stats.Vertices, ShouldEqual, 10)
+		So(stats.Edges, ShouldEqual, 100)
+		So(stats.Density, ShouldEqual, 1.0)
+		So(stats.AverageDegree, ShouldEqual, 20.0)
+		So(stats.Connected, ShouldBeTrue)
+	})
+
+	Convey("Compute the stats of a sparse graph", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, b, 2), ShouldBeNil)
+		So(graph.AddEdge(c, b, 1), ShouldBeNil)
+		So(graph.VertexCount(), ShouldEqual, 3)
+		So(graph.EdgeCount(), ShouldEqual, 3)
+		So(graph.Degrees(), ShouldResemble, map[cspf.Vertex]cspf.Degree{
+			a: {Out: 2},
+			b: {In: 3},
+			c: {Out: 1},
+		})
+
+		stats := graph.Stats()
+		So(stats.Density, ShouldAlmostEqual, 2.0/6.0)
+		So(stats.AverageDegree, ShouldEqual, 2.0)
+		So(stats.Connected, ShouldBeTrue)
+
+		graph.AddNode(d)
+		So(graph.Stats().Connected, ShouldBeFalse)
+	})
+
+	Convey("Compute the stats of a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Stats(), ShouldResemble, cspf.GraphStats{})
+		So(nilGraph.Degrees(), ShouldBeNil)
+	})
+}
+
+func TestDensityAndStrongConnectivity(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	Convey("The fully connected graph is dense and strongly connected", t, func() {
+		//Self-loops do not count towards the density
+		graph, _ := generateFullyConnectedGraph(20, false)
+		So(graph.Density(), ShouldAlmostEqual, 1.0)
+		So(graph.IsStronglyConnected(), ShouldBeTrue)
+	})
+
+	Convey("A chain is sparse and only weakly connected", t, func() {
+		graph := cspf.Graph{}
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.Density(), ShouldAlmostEqual, 2.0/6.0)
+		So(graph.IsStronglyConnected(), ShouldBeFalse)
+
+		So(graph.AddEdge(c, a, 1), ShouldBeNil)
+		So(graph.IsStronglyConnected(), ShouldBeTrue)
+	})
+
+	Convey("Compute the metrics of a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.Density(), ShouldEqual, 0)
+		So(nilGraph.IsStronglyConnected(), ShouldBeFalse)
+	})
+}
+
+func TestCostDistribution(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with known costs", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 10), ShouldBeNil)
+		So(graph.AddEdge(b, c, 5), ShouldBeNil)
+		So(graph.AddEdge(c, a, 100), ShouldBeNil)
+		So(graph.AddEdge(b, a, 5), ShouldBeNil)
+	})
+
+	Convey("Count the edges per cost bucket", t, func() {
+		So(graph.CostHistogram([]uint64{10, 1, 50}), ShouldResemble, map[uint64]int{
+			1:                1,
+			10:               3,
+			50:               0,
+			cspf.Unreachable: 1,
+		})
+		So(graph.CostHistogram(nil), ShouldResemble, map[uint64]int{cspf.Unreachable: 5})
+	})
+
+	Convey("Compute the percentiles of the costs", t, func() {
+		//Sorted costs are 1, 5, 5, 10, 100
+		So(graph.CostPercentile(50), ShouldEqual, 5)
+		So(graph.CostPercentile(0), ShouldEqual, 1)
+		So(graph.CostPercentile(61), ShouldEqual, 10)
+		So(graph.CostPercentile(100), ShouldEqual, 100)
+		So(graph.CostPercentile(150), ShouldEqual, 100)
+	})
+
+	Convey("Summarize an empty and a nil graph", t, func() {
+		empty := cspf.Graph{}
+		So(empty.CostHistogram([]uint64{1}), ShouldResemble, map[uint64]int{1: 0})
+		So(empty.CostPercentile(50), ShouldEqual, 0)
+		var nilGraph *cspf.Graph
+		So(nilGraph.CostHistogram([]uint64{1}), ShouldBeNil)
+		So(nilGraph.CostPercentile(50), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,232 @@
+package cspf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// tagType is the type of a tag value, as preserved by the
+// serialization formats of the graph. Every format relies on
+// the same set of supported types, so that a tag read back
+// from any of them has the type it was written with.
+type tagType byte
+
+// Supported types of the tag values. The values of the
+// constants are part of the binary format.
+const (
+	tagNil tagType = iota
+	tagBool
+	tagString
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagFloat32
+	tagFloat64
+)
+
+// tagTypeNames are the names of the types in the text formats.
+var tagTypeNames = [...]string{
+	tagNil:     "nil",
+	tagBool:    "bool",
+	tagString:  "string",
+	tagInt:     "int",
+	tagInt8:    "int8",
+	tagInt16:   "int16",
+	tagInt32:   "int32",
+	tagInt64:   "int64",
+	tagUint:    "uint",
+	tagUint8:   "uint8",
+	tagUint16:  "uint16",
+	tagUint32:  "uint32",
+	tagUint64:  "uint64",
+	tagFloat32: "float32",
+	tagFloat64: "float64",
+}
+
+func (t tagType) String() string {
+	if int(t) < len(tagTypeNames) {
+		return tagTypeNames[t]
+	}
+	return fmt.Sprintf("tagType(%d)", byte(t))
+}
+
+// parseTagType returns the type with the given name.
+func parseTagType(name string) (tagType, bool) {
+	for t, typeName := range tagTypeNames {
+		if typeName == name {
+			return tagType(t), true
+		}
+	}
+	return 0, false
+}
+
+// typeOfTag returns the type of a tag value. Values of any
+// other type than the supported ones make it fail with
+// ErrUnsupportedTagType.
+func typeOfTag(value interface{}) (tagType, error) {
+	switch value.(type) {
+	case nil:
+		return tagNil, nil
+	case bool:
+		return tagBool, nil
+	case string:
+		return tagString, nil
+	case int:
+		return tagInt, nil
+	case int8:
+		return tagInt8, nil
+	case int16:
+		return tagInt16, nil
+	case int32:
+		return tagInt32, nil
+	case int64:
+		return tagInt64, nil
+	case uint:
+		return tagUint, nil
+	case uint8:
+		return tagUint8, nil
+	case uint16:
+		return tagUint16, nil
+	case uint32:
+		return tagUint32, nil
+	case uint64:
+		return tagUint64, nil
+	case float32:
+		return tagFloat32, nil
+	case float64:
+		return tagFloat64, nil
+	}
+	return 0, fmt.Errorf("%w: %T", ErrUnsupportedTagType, value)
+}
+
+// formatTag returns the type of a tag value and its canonical
+// text encoding: nil is empty, bools are true or false, strings
+// are unchanged, ints and uints are in base 10 and floats are
+// in the shortest form that parses back to the same value at
+// their precision, e.g. 0.1, 1e+21, NaN or +Inf.
+func formatTag(value interface{}) (tagType, string, error) {
+	t, err := typeOfTag(value)
+	if err != nil {
+		return 0, "", err
+	}
+	switch v := value.(type) {
+	case bool:
+		return t, strconv.FormatBool(v), nil
+	case string:
+		return t, v, nil
+	case int:
+		return t, strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return t, strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return t, strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return t, strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return t, strconv.FormatInt(v, 10), nil
+	case uint:
+		return t, strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return t, strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return t, strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return t, strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return t, strconv.FormatUint(v, 10), nil
+	case float32:
+		return t, strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return t, strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+	return t, "", nil
+}
+
+// parseTag converts the canonical text encoding of
+// a tag value back to a value of the given type.
+func parseTag(t tagType, text string) (interface{}, error) {
+	switch t {
+	case tagNil:
+		if text != "" {
+			return nil, fmt.Errorf("nil tag with value %q", text)
+		}
+		return nil, nil
+	case tagBool:
+		if text != "true" && text != "false" {
+			return nil, fmt.Errorf("invalid bool %q", text)
+		}
+		return text == "true", nil
+	case tagString:
+		return text, nil
+	case tagInt, tagInt8, tagInt16, tagInt32, tagInt64:
+		i, err := strconv.ParseInt(text, 10, intBits(t))
+		if err != nil {
+			return nil, err
+		}
+		return intTag(t, i), nil
+	case tagUint, tagUint8, tagUint16, tagUint32, tagUint64:
+		u, err := strconv.ParseUint(text, 10, intBits(t))
+		if err != nil {
+			return nil, err
+		}
+		return uintTag(t, u), nil
+	case tagFloat32:
+		f, err := strconv.ParseFloat(text, 32)
+		return float32(f), err
+	case tagFloat64:
+		return strconv.ParseFloat(text, 64)
+	}
+	return nil, fmt.Errorf("unknown tag type %s", t)
+}
+
+// intBits returns the size in bits of an int or uint type.
+func intBits(t tagType) int {
+	switch t {
+	case tagInt8, tagUint8:
+		return 8
+	case tagInt16, tagUint16:
+		return 16
+	case tagInt32, tagUint32:
+		return 32
+	case tagInt64, tagUint64:
+		return 64
+	}
+	return strconv.IntSize
+}
+
+// intTag converts an int64 to a value of the given int type.
+func intTag(t tagType, i int64) interface{} {
+	switch t {
+	case tagInt8:
+		return int8(i)
+	case tagInt16:
+		return int16(i)
+	case tagInt32:
+		return int32(i)
+	case tagInt64:
+		return i
+	}
+	return int(i)
+}
+
+// uintTag converts a uint64 to a value of the given uint type.
+func uintTag(t tagType, u uint64) interface{} {
+	switch t {
+	case tagUint8:
+		return uint8(u)
+	case tagUint16:
+		return uint16(u)
+	case tagUint32:
+		return uint32(u)
+	case tagUint64:
+		return u
+	}
+	return uint(u)
+}
@@ -0,0 +1,116 @@
+package cspf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// EdgesWithTag lists the edges of the graph that carry a tag
+// with the given key whose value is deeply equal to the given
+// value. Edges are listed by source vertex ID and then in
+// insertion order.
+func (g *Graph) EdgesWithTag(key string, value interface{}) []Edge {
+	if g == nil {
+		return nil
+	}
+	edges := []Edge{}
+	for _, v := range g.Vertices() {
+		for _, edge := range g.VertexSet[v] {
+			if edge.hasTag(key, value) {
+				edges = append(edges, edge)
+			}
+		}
+	}
+	return edges
+}
+
+// RemoveEdgesWithTag removes from the graph all the edges that
+// carry a tag with the given key whose value is deeply equal to
+// the given value, and returns how many were removed.
+// Vertices are left in the graph even if they lose all their edges.
+func (g *Graph) RemoveEdgesWithTag(key string, value interface{}) int {
+	if g == nil {
+		return 0
+	}
+	removed := 0
+	for v, edges := range g.VertexSet {
+		kept := make([]Edge, 0, len(edges))
+		for _, edge := range edges {
+			if edge.hasTag(key, value) {
+				removed++
+				g.record(Mutation{Kind: MutationRemoveEdge, Edge: edge})
+				continue
+			}
+			kept = append(kept, edge)
+		}
+		g.VertexSet[v] = kept
+	}
+	return removed
+}
+
+// SetVertexDefaultTag sets a default tag on a vertex, which all
+// the edges leaving the vertex inherit, including the ones added
+// later, to save tagging every edge of the vertex one by one.
+// Inherited tags are only seen by constraint expressions, e.g.
+// by CSPF, which evaluate the edges on the union of their own tags
+// and of the default tags of their source vertex. The tags of the
+// edge take precedence: a default tag applies only to the edges
+// that have no tag with the same key.
+// The vertex is added to the graph if missing. Default tags are
+// not stored in the edges, but they are part of the comparisons of
+// the graph and of its JSON and binary encodings; ToDOT does not
+// write them.
+func (g *Graph) SetVertexDefaultTag(v Vertex, key string, value interface{}) {
+	if g == nil {
+		return
+	}
+	g.addNode(v)
+	if g.vertexTags == nil {
+		g.vertexTags = make(map[Vertex]map[string]interface{})
+	}
+	if g.vertexTags[v] == nil {
+		g.vertexTags[v] = make(map[string]interface{})
+	}
+	g.vertexTags[v][key] = value
+	g.record(Mutation{Kind: MutationSetVertexDefaultTag, Vertex: v, Tag: Tag{Key: key, Value: value}})
+}
+
+// hasTag reports whether the edge has a tag with the
+// given key whose value is deeply equal to the given one.
+func (e Edge) hasTag(key string, value interface{}) bool {
+	tagValue, ok := e.Tags[key]
+	return ok && reflect.DeepEqual(tagValue, value)
+}
+
+// TagTypeReport maps every tag key used in the graph to the
+// distinct Go types of its values across all the edges, sorted
+// by name. In a well-formed graph every key maps to a single
+// type: more than one type means that expressions involving
+// the key may behave inconsistently from edge to edge.
+func (g *Graph) TagTypeReport() map[string][]string {
+	if g == nil {
+		return nil
+	}
+	types := make(map[string]map[string]bool)
+	for _, edges := range g.VertexSet {
+		for _, edge := range edges {
+			for key, value := range edge.Tags {
+				if types[key] == nil {
+					types[key] = make(map[string]bool)
+				}
+				types[key][fmt.Sprintf("%T", value)] = true
+			}
+		}
+	}
+	report := make(map[string][]string, len(types))
+	for key, set := range types {
+		names := make([]string, 0, len(set))
+		for name := range set {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		report[key] = names
+	}
+	return report
+}
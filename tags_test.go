@@ -0,0 +1,284 @@
+package cspf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEdgesWithTag(t *testing.T) {
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagBlue, cspf.Tag{Key: "bw", Value: 10}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, tagRed, cspf.Tag{Key: "bw", Value: 10}), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagBlue, cspf.Tag{Key: "bw", Value: 100}), ShouldBeNil)
+		So(graph.AddEdge(c, d, 1), ShouldBeNil)
+	})
+
+	Convey("Select all the blue edges", t, func() {
+		edges := graph.EdgesWithTag("link", "blue")
+		So(len(edges), ShouldEqual, 2)
+		So(cspf.Path(edges).String(), ShouldEqual, "a->b->d")
+	})
+
+	Convey("Select all the edges with a numeric tag", t, func() {
+		edges := graph.EdgesWithTag("bw", 10)
+		So(len(edges), ShouldEqual, 2)
+		So(edges[0].To, ShouldResemble, b)
+		So(edges[1].To, ShouldResemble, c)
+		//Values of a different type are not equal
+		So(len(graph.EdgesWithTag("bw", 10.0)), ShouldEqual, 0)
+	})
+
+	Convey("No edge matches an unknown tag", t, func() {
+		So(graph.EdgesWithTag("color", "blue"), ShouldBeEmpty)
+	})
+
+	Convey("Call EdgesWithTag on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.EdgesWithTag("link", "blue"), ShouldBeNil)
+	})
+}
+
+func TestTagTypeReport(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{}
+
+	Convey("An empty graph has no tags", t, func() {
+		So(graph.TagTypeReport(), ShouldBeEmpty)
+	})
+
+	Convey("Populate the graph mixing value types for the same key", t, func() {
+		So(graph.AddEdge(a, b, 1, cspf.Tag{Key: "cost", Value: 10}, cspf.Tag{Key: "link", Value: "blue"}), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1, cspf.Tag{Key: "cost", Value: "10"}, cspf.Tag{Key: "link", Value: "red"}), ShouldBeNil)
+		So(graph.AddEdge(a, c, 1, cspf.Tag{Key: "cost", Value: 20}), ShouldBeNil)
+	})
+
+	Convey("Report the distinct types of every key", t, func() {
+		So(graph.TagTypeReport(), ShouldResemble, map[string][]string{
+			"cost": {"int", "string"},
+			"link": {"string"},
+		})
+	})
+
+	Convey("Call TagTypeReport on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.TagTypeReport(), ShouldBeNil)
+	})
+}
+
+func TestRemoveEdgesWithTag(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+	tagBlue := cspf.Tag{
+		Key:   "link",
+		Value: "blue",
+	}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1, tagRed), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2, tagBlue), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2, tagBlue), ShouldBeNil)
+	})
+
+	Convey("Remove all the red edges", t, func() {
+		So(graph.RemoveEdgesWithTag("link", "red"), ShouldEqual, 2)
+		So(graph.EdgesWithTag("link", "red"), ShouldBeEmpty)
+		//Vertices stay in the graph
+		So(len(graph.VertexSet), ShouldEqual, 4)
+
+		Convey("SPF only uses the remaining links", func() {
+			spf, err := graph.SPF(a, d)
+			So(err, ShouldBeNil)
+			paths := spf.Paths(a, d)
+			So(len(paths), ShouldEqual, 1)
+			So(cspf.Path(paths[0]).String(), ShouldEqual, "a->c->d")
+		})
+	})
+
+	Convey("Removing an unknown tag removes nothing", t, func() {
+		So(graph.RemoveEdgesWithTag("link", "green"), ShouldEqual, 0)
+	})
+
+	Convey("Call RemoveEdgesWithTag on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.RemoveEdgesWithTag("link", "red"), ShouldEqual, 0)
+	})
+}
+
+func TestSetVertexDefaultTag(t *testing.T) {
+	tagRed := cspf.Tag{
+		Key:   "link",
+		Value: "red",
+	}
+
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+	d := cspf.Vertex{ID: "d"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, d, 1), ShouldBeNil)
+		So(graph.AddEdge(a, c, 2), ShouldBeNil)
+		So(graph.AddEdge(c, d, 2), ShouldBeNil)
+	})
+
+	Convey("Untagged edges do not satisfy the constraint", t, func() {
+		cspfGraph, err := graph.CSPF(a, d, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldBeEmpty)
+	})
+
+	Convey("All the edges of a vertex inherit its default tag", t, func() {
+		graph.SetVertexDefaultTag(a, "link", "blue")
+		graph.SetVertexDefaultTag(c, "link", "blue")
+		//Edges added later inherit it too
+		So(graph.AddEdge(c, b, 5), ShouldBeNil)
+		cspfGraph, err := graph.CSPF(a, d, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		//The default tags are not stored in the edges
+		So(graph.EdgesWithTag("link", "blue"), ShouldBeEmpty)
+	})
+
+	Convey("The tags of the edge take precedence", t, func() {
+		So(graph.AddEdge(a, d, 1, tagRed), ShouldBeNil)
+		cspfGraph, err := graph.CSPF(a, d, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+		cspfGraph, err = graph.CSPF(a, d, `link == "red"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->d"})
+	})
+
+	Convey("Copies of the graph keep the default tags", t, func() {
+		normalized := graph.WithNormalizedCosts(10)
+		cspfGraph, err := normalized.CSPF(a, d, `anyTag("blue")`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, d), ShouldResemble, []string{"a->c->d"})
+	})
+
+	Convey("Set a default tag on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(func() { nilGraph.SetVertexDefaultTag(a, "link", "blue") }, ShouldNotPanic)
+	})
+}
+
+func TestVertexDefaultTagConsistency(t *testing.T) {
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	c := cspf.Vertex{ID: "c"}
+
+	graph := cspf.Graph{RecordMutations: true}
+
+	Convey("Populate the graph with no error", t, func() {
+		So(graph.AddEdge(a, b, 1), ShouldBeNil)
+		So(graph.AddEdge(b, c, 1), ShouldBeNil)
+		graph.SetVertexDefaultTag(a, "link", "blue")
+		graph.SetVertexDefaultTag(b, "link", "blue")
+	})
+
+	Convey("The change is recorded in the mutation log", t, func() {
+		mutations := graph.Mutations()
+		So(mutations[len(mutations)-1], ShouldResemble, cspf.Mutation{
+			Kind:   cspf.MutationSetVertexDefaultTag,
+			Vertex: b,
+			Tag:    cspf.Tag{Key: "link", Value: "blue"},
+		})
+	})
+
+	Convey("Predicates see the inherited tags", t, func() {
+		cspfGraph, err := graph.CSPFPredicate(a, c, cspf.Eq("link", "blue"))
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, c), ShouldResemble, []string{"a->b->c"})
+		cspfGraph, err = graph.CSPFEqual(a, c, "link", "blue")
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, c), ShouldResemble, []string{"a->b->c"})
+	})
+
+	Convey("Default tags are part of the comparisons", t, func() {
+		untagged := cspf.Graph{}
+		So(untagged.AddEdge(a, b, 1), ShouldBeNil)
+		So(untagged.AddEdge(b, c, 1), ShouldBeNil)
+		So(graph.Equal(&untagged), ShouldBeFalse)
+		So(graph.Fingerprint(), ShouldNotEqual, untagged.Fingerprint())
+		So(graph.Canonical(), ShouldContainSubstring, `default "link"=string("blue")`)
+	})
+
+	Convey("Default tags survive a JSON round trip", t, func() {
+		encoded, err := json.Marshal(&graph)
+		So(err, ShouldBeNil)
+		decoded := cspf.Graph{}
+		So(json.Unmarshal(encoded, &decoded), ShouldBeNil)
+		So(decoded.Equal(&graph), ShouldBeTrue)
+		cspfGraph, err := decoded.CSPF(a, c, `link == "blue"`)
+		So(err, ShouldBeNil)
+		So(cspfGraph.PathStrings(a, c), ShouldResemble, []string{"a->b->c"})
+	})
+
+	Convey("Default tags survive a binary round trip", t, func() {
+		var buf bytes.Buffer
+		_, err := graph.WriteTo(&buf)
+		So(err, ShouldBeNil)
+		decoded := cspf.Graph{}
+		_, err = decoded.ReadFrom(&buf)
+		So(err, ShouldBeNil)
+		So(decoded.Equal(&graph), ShouldBeTrue)
+	})
+
+	Convey("Decoding another graph drops the stale default tags", t, func() {
+		other := cspf.Graph{}
+		So(other.AddEdge(a, c, 1), ShouldBeNil)
+		encoded, err := json.Marshal(&other)
+		So(err, ShouldBeNil)
+		var buf bytes.Buffer
+		_, err = other.WriteTo(&buf)
+		So(err, ShouldBeNil)
+
+		fromJSON := graph.WithNormalizedCosts(1)
+		So(json.Unmarshal(encoded, fromJSON), ShouldBeNil)
+		fromBinary := graph.WithNormalizedCosts(1)
+		_, err = fromBinary.ReadFrom(&buf)
+		So(err, ShouldBeNil)
+		for _, decoded := range []*cspf.Graph{fromJSON, fromBinary} {
+			So(decoded.Equal(&other), ShouldBeTrue)
+			cspfGraph, err := decoded.CSPF(a, c, `link == "blue"`)
+			So(err, ShouldBeNil)
+			So(cspfGraph.PathStrings(a, c), ShouldBeEmpty)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package cspf
+
+// TreeDepth returns the depth of the graph seen as a tree
+// rooted at the given vertex, such as the result graph of SPF:
+// the largest number of hops needed to reach a vertex from the
+// root. On graphs that are not trees, every vertex counts with
+// its smallest number of hops. A root that is not part of the
+// graph has depth 0.
+func (g *Graph) TreeDepth(root Vertex) int {
+	if g == nil {
+		return 0
+	}
+	hops := map[Vertex]int{root: 0}
+	frontier := []Vertex{root}
+	depth := 0
+	for len(frontier) > 0 {
+		v := frontier[0]
+		frontier = frontier[1:]
+		for _, edge := range g.VertexSet[v] {
+			if _, ok := hops[edge.To]; !ok {
+				hops[edge.To] = hops[v] + 1
+				if hops[edge.To] > depth {
+					depth = hops[edge.To]
+				}
+				frontier = append(frontier, edge.To)
+			}
+		}
+	}
+	return depth
+}
+
+// Leaves returns the vertices of the graph that have no
+// outgoing edges, sorted by ID. In the result graph of
+// SPF, they are the leaves of the shortest-path tree.
+func (g *Graph) Leaves() []Vertex {
+	if g == nil {
+		return nil
+	}
+	leaves := []Vertex{}
+	for _, v := range g.Vertices() {
+		if len(g.VertexSet[v]) == 0 {
+			leaves = append(leaves, v)
+		}
+	}
+	return leaves
+}
@@ -0,0 +1,50 @@
+package cspf_test
+
+import (
+	"testing"
+
+	"github.com/bigmikes/cspf"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTreeDepthAndLeaves(t *testing.T) {
+	root := cspf.Vertex{ID: "root"}
+	a := cspf.Vertex{ID: "a"}
+	b := cspf.Vertex{ID: "b"}
+	a1 := cspf.Vertex{ID: "a1"}
+	a2 := cspf.Vertex{ID: "a2"}
+	b1 := cspf.Vertex{ID: "b1"}
+	b2 := cspf.Vertex{ID: "b2"}
+
+	graph := cspf.Graph{}
+
+	Convey("Populate a balanced tree with a shortcut", t, func() {
+		So(graph.AddEdge(root, a, 1), ShouldBeNil)
+		So(graph.AddEdge(root, b, 1), ShouldBeNil)
+		So(graph.AddEdge(a, a1, 1), ShouldBeNil)
+		So(graph.AddEdge(a, a2, 1), ShouldBeNil)
+		So(graph.AddEdge(b, b1, 1), ShouldBeNil)
+		So(graph.AddEdge(b, b2, 1), ShouldBeNil)
+		//Not part of the shortest-path tree
+		So(graph.AddEdge(a1, b2, 5), ShouldBeNil)
+	})
+
+	Convey("Characterize the shortest-path tree", t, func() {
+		spf, err := graph.SPF(root, b2)
+		So(err, ShouldBeNil)
+		So(spf.TreeDepth(root), ShouldEqual, 2)
+		So(spf.TreeDepth(a), ShouldEqual, 1)
+		So(spf.Leaves(), ShouldResemble, []cspf.Vertex{a1, a2, b1, b2})
+	})
+
+	Convey("A root out of the graph has depth 0", t, func() {
+		So(graph.TreeDepth(cspf.Vertex{ID: "x"}), ShouldEqual, 0)
+	})
+
+	Convey("Call TreeDepth and Leaves on a nil graph", t, func() {
+		var nilGraph *cspf.Graph
+		So(nilGraph.TreeDepth(root), ShouldEqual, 0)
+		So(nilGraph.Leaves(), ShouldBeNil)
+	})
+}